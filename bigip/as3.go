@@ -0,0 +1,149 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// AS3Info returns the version of AS3 installed on BIG-IP, an error tells clearly if AS3 is not installed.
+func (bc *BIGIPContext) AS3Info() (*AS3Info, error) {
+	jresp, err := bc.requestJSON("GET", as3UriPrefix+"/info", nil)
+	if err != nil {
+		var berr *BigipError
+		if errors.As(err, &berr) && berr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("AS3 is not installed on %s: %w", bc.URL, err)
+		}
+		return nil, err
+	}
+	info := AS3Info{}
+	if err := remarshal(jresp, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetAS3Declaration returns the current declaration of the tenants, or of all tenants if none is given.
+// An empty declaration is returned if nothing is deployed.
+func (bc *BIGIPContext) GetAS3Declaration(tenants ...string) (map[string]interface{}, error) {
+	uri := as3UriPrefix + "/declare"
+	if len(tenants) > 0 {
+		uri += "/" + strings.Join(tenants, ",")
+	}
+	decl, err := bc.requestJSON("GET", uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retriving as3 declaration %w", err)
+	}
+	return decl, nil
+}
+
+// DeployAS3 posts the declaration of class AS3 or ADC asynchronously, and polls the task until it's done.
+// The per-tenant results are returned, with an error if any tenant fails.
+func (bc *BIGIPContext) DeployAS3(declaration map[string]interface{}) ([]AS3Result, error) {
+	defer utils.TimeItToPrometheus()()
+
+	switch declaration["class"] {
+	case "AS3", "ADC":
+	default:
+		return nil, fmt.Errorf("not support, class %v", declaration["class"])
+	}
+	jresp, err := bc.requestJSON("POST", as3UriPrefix+"/declare?async=true", declaration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post as3 declaration: %w", err)
+	}
+	taskId, f := jresp["id"].(string)
+	if !f {
+		return nil, fmt.Errorf("strange.. id not found from %v", jresp)
+	}
+	return bc.waitAS3Task(taskId)
+}
+
+// DeleteAS3Tenants deletes the tenants with all applications in them.
+func (bc *BIGIPContext) DeleteAS3Tenants(tenants ...string) ([]AS3Result, error) {
+	defer utils.TimeItToPrometheus()()
+
+	if len(tenants) == 0 {
+		return []AS3Result{}, nil
+	}
+	jresp, err := bc.requestJSON("DELETE", as3UriPrefix+"/declare/"+strings.Join(tenants, ","), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete as3 tenants %s: %w", tenants, err)
+	}
+	return as3Results(jresp)
+}
+
+func (bc *BIGIPContext) waitAS3Task(taskId string) ([]AS3Result, error) {
+	slog := utils.LogFromContext(bc.Context)
+
+	for attempt := 1; ; attempt++ {
+		jresp, err := bc.requestJSON("GET", as3UriPrefix+"/task/"+taskId, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status of as3 task %s: %w", taskId, err)
+		}
+		results, _ := jresp["results"].([]interface{})
+		inProgress := len(results) == 0
+		if len(results) == 1 {
+			message, _ := results[0].(map[string]interface{})["message"].(string)
+			inProgress = message == "in progress" || message == "pending"
+		}
+		if !inProgress {
+			return as3Results(jresp)
+		}
+		slog.Debugf("as3 task %s is in progress", taskId)
+		select {
+		case <-bc.ctx().Done():
+			return nil, fmt.Errorf("waiting for as3 task %s aborted: %w", taskId, bc.ctx().Err())
+		case <-time.After(taskPollPolicy.backoff(attempt)):
+		}
+	}
+}
+
+// as3Results parses the results of the response, with an error merged from the failed tenants.
+func as3Results(jresp map[string]interface{}) ([]AS3Result, error) {
+	var body struct {
+		Results []AS3Result `json:"results"`
+	}
+	if err := remarshal(jresp, &body); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(body.Results, func(i, j int) bool { return body.Results[i].Tenant < body.Results[j].Tenant })
+
+	errs := []error{}
+	for _, r := range body.Results {
+		if r.Code >= 300 {
+			errs = append(errs, fmt.Errorf("tenant %s: %d, %s %s", r.Tenant, r.Code, r.Message, strings.Join(r.Errors, "; ")))
+		}
+	}
+	return body.Results, utils.MergeErrors(errs)
+}
+
+// AS3Tenants returns the names of the tenants in the declaration of class AS3 or ADC.
+func AS3Tenants(declaration map[string]interface{}) []string {
+	adc := declaration
+	if declaration["class"] == "AS3" {
+		adc, _ = declaration["declaration"].(map[string]interface{})
+	}
+	tenants := []string{}
+	for k, v := range adc {
+		if m, ok := v.(map[string]interface{}); ok && m["class"] == "Tenant" {
+			tenants = append(tenants, k)
+		}
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// remarshal converts the parsed JSON in to the typed out.
+func remarshal(in, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
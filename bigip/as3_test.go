@@ -0,0 +1,73 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBIGIPContext_DeployAS3(t *testing.T) {
+	polled := 0
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/mgmt/shared/appsvcs/declare" && r.URL.Query().Get("async") == "true":
+			w.WriteHeader(202)
+			fmt.Fprint(w, `{"id":"a1b2","results":[{"message":"Declaration successfully submitted","tenant":"","code":0}]}`)
+		case r.URL.Path == "/mgmt/shared/appsvcs/task/a1b2":
+			polled++
+			if polled < 2 {
+				fmt.Fprint(w, `{"id":"a1b2","results":[{"message":"in progress","tenant":"","code":0}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"id":"a1b2","results":[
+				{"message":"success","tenant":"t2","code":200,"host":"localhost","runTime":1000},
+				{"message":"declaration failed","tenant":"t1","code":422,"errors":["/t1/app: should NOT have additional properties"]}
+			]}`)
+		default:
+			w.WriteHeader(404)
+		}
+	})
+	defer done()
+
+	if _, err := bc.DeployAS3(map[string]interface{}{"class": "Tenant"}); err == nil {
+		t.Errorf("class Tenant should not be supported")
+	}
+	results, err := bc.DeployAS3(map[string]interface{}{"class": "ADC", "schemaVersion": "3.0.0"})
+	if err == nil || !strings.Contains(err.Error(), "tenant t1: 422") {
+		t.Errorf("expected failure of tenant t1, got %v", err)
+	}
+	want := []AS3Result{
+		{Code: 422, Message: "declaration failed", Tenant: "t1", Errors: []string{"/t1/app: should NOT have additional properties"}},
+		{Code: 200, Message: "success", Tenant: "t2", Host: "localhost", RunTime: 1000},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %+v, want %+v", results, want)
+	}
+
+	if _, err := bc.AS3Info(); err == nil || !strings.Contains(err.Error(), "AS3 is not installed") {
+		t.Errorf("expected AS3 not installed, got %v", err)
+	}
+}
+
+func TestAS3Tenants(t *testing.T) {
+	decl := map[string]interface{}{}
+	json.Unmarshal([]byte(`{
+		"class": "AS3",
+		"declaration": {
+			"class": "ADC",
+			"schemaVersion": "3.36.0",
+			"id": "example",
+			"t2": {"class": "Tenant"},
+			"t1": {"class": "Tenant", "app": {"class": "Application"}}
+		}
+	}`), &decl)
+	if got := AS3Tenants(decl); !reflect.DeepEqual(got, []string{"t1", "t2"}) {
+		t.Errorf("AS3Tenants() = %v", got)
+	}
+	if got := AS3Tenants(decl["declaration"].(map[string]interface{})); !reflect.DeepEqual(got, []string{"t1", "t2"}) {
+		t.Errorf("AS3Tenants() of ADC = %v", got)
+	}
+}
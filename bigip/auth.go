@@ -0,0 +1,107 @@
+package f5_bigip
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// NewWithToken does the same as New, but authenticates with X-F5-Auth-Token
+// acquired from /mgmt/shared/authn/login instead of the Basic header.
+// loginProviderName is the remote auth provider configured on BIG-IP,
+// i.e. "tmos" for local users, or the name of an LDAP/RADIUS provider.
+func NewWithToken(url, user, password, loginProviderName string) *BIGIP {
+	bip, err := NewBIGIP(url, WithTokenAuth(user, password, loginProviderName), WithPartitions("cis-c-tenant"))
+	if err != nil {
+		panic(err)
+	}
+	return bip
+}
+
+func basicAuthorization(user, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+}
+
+// authHeaders returns the authentication headers for the next call,
+// the token is (re)acquired if it is absent or about to expire.
+func (bc *BIGIPContext) authHeaders() (map[string]string, error) {
+	if bc.token == nil {
+		return map[string]string{"Authorization": bc.Authorization}, nil
+	}
+
+	bc.token.mutex.Lock()
+	defer bc.token.mutex.Unlock()
+
+	if bc.token.token == "" || time.Now().Add(tokenRefreshAhead).After(bc.token.expiration) {
+		if err := bc.login(); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]string{"X-F5-Auth-Token": bc.token.token}, nil
+}
+
+// invalidateToken drops the given token so that the next call logins again.
+// The token is compared before dropping, in case that it was refreshed by others already.
+func (bc *BIGIPContext) invalidateToken(token string) {
+	if bc.token == nil {
+		return
+	}
+	bc.token.mutex.Lock()
+	defer bc.token.mutex.Unlock()
+
+	if bc.token.token == token {
+		bc.token.token = ""
+	}
+}
+
+// login requests a new token, the caller should hold bc.token.mutex.
+func (bc *BIGIPContext) login() error {
+	slog := utils.LogFromContext(bc.Context)
+
+	url := bc.URL + "/mgmt/shared/authn/login"
+	payload, _ := json.Marshal(map[string]string{
+		"username":          bc.token.username,
+		"password":          bc.token.password,
+		"loginProviderName": bc.token.loginProviderName,
+	})
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	code, resp, err := utils.HttpRequestWithContext(bc.ctx(), bc.client, url, "POST", string(payload), headers)
+	if err != nil {
+		return err
+	}
+	if code == 401 {
+		// wrong credentials won't be fixed by retrying.
+		return fmt.Errorf("failed to login %s as %s: %d, %s", bc.URL, bc.token.username, code, resp)
+	}
+	if err := assertBigipResp("POST", url, code, resp); err != nil {
+		return err
+	}
+
+	var jresp struct {
+		Token struct {
+			Token            string `json:"token"`
+			Timeout          int64  `json:"timeout"`
+			ExpirationMicros int64  `json:"expirationMicros"`
+		} `json:"token"`
+	}
+	if err := json.Unmarshal(resp, &jresp); err != nil {
+		return err
+	}
+	if jresp.Token.Token == "" {
+		return fmt.Errorf("strange.. token not found from login response of %s", bc.URL)
+	}
+
+	bc.token.token = jresp.Token.Token
+	if jresp.Token.ExpirationMicros > 0 {
+		bc.token.expiration = time.UnixMicro(jresp.Token.ExpirationMicros)
+	} else {
+		bc.token.expiration = time.Now().Add(time.Duration(jresp.Token.Timeout) * time.Second)
+	}
+	slog.Debugf("logged in %s as %s, token expires at %s", bc.URL, bc.token.username, bc.token.expiration)
+	return nil
+}
@@ -0,0 +1,149 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer serves the login of BIG-IP, the n-th token is "t<n>", expiring after expires.
+type tokenServer struct {
+	mutex   sync.Mutex
+	logins  []map[string]string
+	expires time.Duration
+	// accepted tells if the token is valid for the iControl calls.
+	accepted func(token string) bool
+	tokens   []string
+}
+
+func (ts *tokenServer) handle(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mgmt/shared/authn/login" {
+			// let the concurrent callers pile up.
+			time.Sleep(20 * time.Millisecond)
+			body := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&body)
+			ts.mutex.Lock()
+			ts.logins = append(ts.logins, body)
+			n := len(ts.logins)
+			ts.mutex.Unlock()
+			fmt.Fprintf(w, `{"token":{"token":"t%d","timeout":1200,"expirationMicros":%d}}`, n, time.Now().Add(ts.expires).UnixMicro())
+			return
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("unexpected Authorization header in token mode: %s", r.Header.Get("Authorization"))
+		}
+		token := r.Header.Get("X-F5-Auth-Token")
+		ts.mutex.Lock()
+		ts.tokens = append(ts.tokens, token)
+		ts.mutex.Unlock()
+		if ts.accepted != nil && !ts.accepted(token) {
+			w.WriteHeader(401)
+			fmt.Fprint(w, `{"code":401,"message":"X-F5-Auth-Token does not exist."}`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}
+}
+
+// getVersion calls BIG-IP with the authentication of bc, and returns the status code.
+func getVersion(bc *BIGIPContext) (int, error) {
+	code, _, err := httpRequest(bc, bc.URL+"/mgmt/tm/sys/version", "GET", "", map[string]string{})
+	return code, err
+}
+
+func TestBIGIPContext_tokenAuth(t *testing.T) {
+	ts := &tokenServer{expires: time.Hour}
+	bc, done := newTestBIGIPContext(t, ts.handle(t), WithTokenAuth("admin", "secret", "ldap"))
+	defer done()
+
+	for i := 0; i < 2; i++ {
+		if _, err := getVersion(bc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string]string{"username": "admin", "password": "secret", "loginProviderName": "ldap"}
+	if len(ts.logins) != 1 || fmt.Sprint(ts.logins[0]) != fmt.Sprint(want) {
+		t.Errorf("logins = %v, want once with %v", ts.logins, want)
+	}
+	if fmt.Sprint(ts.tokens) != "[t1 t1]" {
+		t.Errorf("tokens used = %v", ts.tokens)
+	}
+
+	dts := &tokenServer{expires: time.Hour}
+	dbc, ddone := newTestBIGIPContext(t, dts.handle(t), WithTokenAuth("admin", "secret", ""))
+	defer ddone()
+	if _, err := getVersion(dbc); err != nil {
+		t.Fatal(err)
+	}
+	if len(dts.logins) != 1 || dts.logins[0]["loginProviderName"] != DefaultLoginProvider {
+		t.Errorf("logins = %v, want the default provider", dts.logins)
+	}
+}
+
+func TestBIGIPContext_tokenRefresh(t *testing.T) {
+	// the token expires within tokenRefreshAhead, so it's refreshed before each call.
+	ts := &tokenServer{expires: tokenRefreshAhead / 2}
+	bc, done := newTestBIGIPContext(t, ts.handle(t), WithTokenAuth("admin", "secret", ""))
+	defer done()
+
+	for i := 0; i < 2; i++ {
+		if _, err := getVersion(bc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(ts.logins) != 2 || fmt.Sprint(ts.tokens) != "[t1 t2]" {
+		t.Errorf("logins = %d, tokens used = %v", len(ts.logins), ts.tokens)
+	}
+}
+
+func TestBIGIPContext_tokenRevoked(t *testing.T) {
+	// t1 is revoked on BIG-IP, the call is sent once more with a new token.
+	ts := &tokenServer{expires: time.Hour, accepted: func(token string) bool { return token != "t1" }}
+	bc, done := newTestBIGIPContext(t, ts.handle(t), WithTokenAuth("admin", "secret", ""))
+	defer done()
+
+	if _, err := getVersion(bc); err != nil {
+		t.Fatal(err)
+	}
+	if len(ts.logins) != 2 || fmt.Sprint(ts.tokens) != "[t1 t2]" {
+		t.Errorf("logins = %d, tokens used = %v", len(ts.logins), ts.tokens)
+	}
+
+	// no token is accepted, re-login only once.
+	rts := &tokenServer{expires: time.Hour, accepted: func(string) bool { return false }}
+	rbc, rdone := newTestBIGIPContext(t, rts.handle(t), WithTokenAuth("admin", "secret", ""))
+	defer rdone()
+	if code, err := getVersion(rbc); err != nil || code != 401 {
+		t.Errorf("expected 401, got %d, %v", code, err)
+	}
+	if len(rts.logins) != 2 || len(rts.tokens) != 2 {
+		t.Errorf("logins = %d, tokens used = %v, want re-login once", len(rts.logins), rts.tokens)
+	}
+}
+
+func TestBIGIPContext_tokenShared(t *testing.T) {
+	ts := &tokenServer{expires: time.Hour}
+	bc, done := newTestBIGIPContext(t, ts.handle(t), WithTokenAuth("admin", "secret", ""))
+	defer done()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		// the copies of BIGIP share the token.
+		cbc := &BIGIPContext{BIGIP: bc.BIGIP, Context: bc.Context}
+		go func() {
+			defer wg.Done()
+			if _, err := getVersion(cbc); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(ts.logins) != 1 || len(ts.tokens) != 10 {
+		t.Errorf("logins = %d, calls = %d, want 1 login for 10 calls", len(ts.logins), len(ts.tokens))
+	}
+}
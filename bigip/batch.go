@@ -0,0 +1,200 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// DoRestRequestsInBatches executes the rest requests in transactions of at most the commands limited by
+// WithMaxTransCommands, one after another, stopping at the first failed batch.
+// The result of every batch is returned, telling which ones are committed. The error is *BatchError if any fails.
+func (bc *BIGIPContext) DoRestRequestsInBatches(rr *[]RestRequest) ([]BatchResult, error) {
+	slog := utils.LogFromContext(bc.Context)
+	if rr == nil || len(*rr) == 0 {
+		slog.Debugf("empty rest requests, skip deploying")
+		return []BatchResult{}, nil
+	}
+
+	batches := splitBatches(*rr, bc.maxTransCommands)
+	results := make([]BatchResult, len(batches))
+	for i, batch := range batches {
+		results[i].Requests = batch
+	}
+	for i := range results {
+		slog.Debugf("committing batch %d of %d with %d requests", i+1, len(results), len(results[i].Requests))
+		err := bc.withRetry("transaction", func() error {
+			return bc.doRestRequestsInTrans(&results[i].Requests)
+		})
+		if err != nil {
+			results[i].Err = err
+			return results, &BatchError{Results: results}
+		}
+		results[i].Done = true
+		bc.schedule(results[i].Requests)
+	}
+	return results, nil
+}
+
+// splitBatches splits the requests sorted by layoutCmds into batches of at most max commands in transaction.
+// The requests are cut only where the kind moves to another item of ResOrder or the deletion starts,
+// so the dependencies are always in an earlier batch than their dependents. A kind alone exceeding max is split
+// further, after ordered by the references within the kind, like defaultsFrom of profiles and monitors, or the
+// nested folders, see orderInKind.
+// The requests not in transaction and the NOPE ones stay where they are and don't count.
+func splitBatches(rr []RestRequest, max int) [][]RestRequest {
+	if max <= 0 || transCommandCount(rr) <= max {
+		return [][]RestRequest{rr}
+	}
+
+	// segments of consecutive requests with the same ResOrder index and phase
+	segments := [][]RestRequest{}
+	lastKey := ""
+	for _, r := range rr {
+		key := lastKey
+		if isTransCommand(r) {
+			key = batchKey(r)
+		}
+		if len(segments) == 0 || key != lastKey {
+			segments = append(segments, []RestRequest{})
+		}
+		segments[len(segments)-1] = append(segments[len(segments)-1], r)
+		lastKey = key
+	}
+
+	batches := [][]RestRequest{}
+	batch, count := []RestRequest{}, 0
+	for _, seg := range segments {
+		n := transCommandCount(seg)
+		if n > max {
+			seg = orderInKind(seg)
+		}
+		if count > 0 && count+n > max {
+			batches = append(batches, batch)
+			batch, count = []RestRequest{}, 0
+		}
+		for _, r := range seg {
+			if isTransCommand(r) {
+				if count == max {
+					batches = append(batches, batch)
+					batch, count = []RestRequest{}, 0
+				}
+				count++
+			}
+			batch = append(batch, r)
+		}
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// orderInKind orders the requests of the same kind so that the resources referred by the others come first,
+// or last when deleting: a resource refers to another one if its body has the full path of the other one as a value,
+// like defaultsFrom, or it's under the other one, like a nested folder. The order is kept if nothing is referred.
+func orderInKind(seg []RestRequest) []RestRequest {
+	paths := map[string]int{}
+	for i, r := range seg {
+		if isTransCommand(r) {
+			paths["/"+uriname(r.Partition, r.Subfolder, r.ResName)] = i
+		}
+	}
+	deps := make([][]int, len(seg))
+	referred := false
+	for i, r := range seg {
+		if !isTransCommand(r) {
+			continue
+		}
+		p := "/" + uriname(r.Partition, r.Subfolder, r.ResName)
+		for q, j := range paths {
+			if j != i && (strings.HasPrefix(p, q+"/") || refersTo(r.Body, q)) {
+				deps[i] = append(deps[i], j)
+				referred = true
+			}
+		}
+		sort.Ints(deps[i])
+	}
+	if !referred {
+		return seg
+	}
+
+	// depth first, the cycles are broken by the original order.
+	ordered := []RestRequest{}
+	visited := make([]bool, len(seg))
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		for _, j := range deps[i] {
+			visit(j)
+		}
+		ordered = append(ordered, seg[i])
+	}
+	for i := range seg {
+		visit(i)
+	}
+	for _, r := range seg {
+		if isTransCommand(r) && r.Method == "DELETE" {
+			for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+				ordered[i], ordered[j] = ordered[j], ordered[i]
+			}
+			break
+		}
+	}
+	return ordered
+}
+
+// refersTo tells if path is a string value in the body.
+func refersTo(body interface{}, path string) bool {
+	switch b := body.(type) {
+	case string:
+		return b == path
+	case map[string]interface{}:
+		for _, v := range b {
+			if refersTo(v, path) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range b {
+			if refersTo(v, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isTransCommand(r RestRequest) bool {
+	return r.WithTrans && r.Method != "NOPE" && r.ScheduleIt == ""
+}
+
+func transCommandCount(rr []RestRequest) int {
+	count := 0
+	for _, r := range rr {
+		if isTransCommand(r) {
+			count++
+		}
+	}
+	return count
+}
+
+// batchKey tells the boundary of batches: the phase of creating/updating or deleting, and the position in ResOrder.
+func batchKey(r RestRequest) string {
+	phase := "deploy"
+	if r.Method == "DELETE" {
+		phase = "delete"
+	}
+	for i, k := range ResOrder {
+		if regexp.MustCompile(k).MatchString(r.Kind) {
+			return phase + "/" + fmt.Sprint(i)
+		}
+	}
+	return phase + "/" + r.Kind
+}
@@ -0,0 +1,143 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// NewTmshCommand builds a tmsh command, like NewTmshCommand("modify", "sys", "db", name, "value", value).
+// Each argument is one tmsh word, quoted if needed, so that values cannot change the command.
+func NewTmshCommand(args ...string) *Command {
+	return (&Command{tmsh: true}).Arg(args...)
+}
+
+// NewBashCommand builds a bash command, like NewBashCommand("rm", "-f", path).
+// Each argument is single-quoted, so that values cannot change the command.
+func NewBashCommand(name string, args ...string) *Command {
+	return (&Command{}).Arg(name).Arg(args...)
+}
+
+// Arg appends the arguments to the command.
+func (c *Command) Arg(args ...string) *Command {
+	for _, a := range args {
+		c.words = append(c.words, c.quote(a))
+	}
+	return c
+}
+
+// Block appends the arguments enclosed with braces, like "partitions { p1 p2 }" of tmsh.
+func (c *Command) Block(args ...string) *Command {
+	c.words = append(c.words, "{")
+	c.Arg(args...)
+	c.words = append(c.words, "}")
+	return c
+}
+
+// String returns the bash command line.
+func (c *Command) String() string {
+	line := strings.Join(c.words, " ")
+	if c.tmsh {
+		return "tmsh -c " + shellQuote(line)
+	}
+	return line
+}
+
+// utilCmdArgs returns the utilCmdArgs of /mgmt/tm/util/bash running the command.
+func (c *Command) utilCmdArgs() string {
+	return "-c " + shellQuote(c.String())
+}
+
+func (c *Command) quote(s string) string {
+	if c.tmsh {
+		return tmshQuote(s)
+	}
+	return shellQuote(s)
+}
+
+// Run runs the command on BIG-IP via /mgmt/tm/util/bash.
+// An error is returned along with the result if the command exits with non-zero status.
+func (bc *BIGIPContext) Run(cmd *Command) (*CommandResult, error) {
+	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(bc.Context)
+
+	// stdout and stderr are merged into commandResult, split them by the length of stdout,
+	// which is kept in a file as it is, since $(...) drops the trailing newlines.
+	script := fmt.Sprintf(`export LC_ALL=C; o=$(mktemp); e=$(mktemp); %s >"$o" 2>"$e"; r=$?; printf '%%d %%d\n' "$r" "$(wc -c <"$o")"; cat "$o" "$e"; rm -f "$o" "$e"`, cmd.String())
+	body := map[string]interface{}{
+		"command":     "run",
+		"utilCmdArgs": "-c " + shellQuote(script),
+	}
+	url := bc.URL + "/mgmt/tm/util/bash"
+	method := "POST"
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	bbody, _ := json.Marshal(body)
+	defer utils.TimeItTrace(slog)("run: %s", cmd.String())
+	code, resp, err := httpRequest(bc, url, method, string(bbody), headers)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBigipResp(method, url, code, resp); err != nil {
+		return nil, err
+	}
+
+	var jresp struct {
+		CommandResult string `json:"commandResult"`
+	}
+	if err := json.Unmarshal(resp, &jresp); err != nil {
+		return nil, err
+	}
+	result, err := parseCommandResult(jresp.CommandResult)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the result of %s: %s", cmd.String(), err.Error())
+	}
+	if result.ExitStatus != 0 {
+		return result, fmt.Errorf("command %s exited with %d: %s", cmd.String(), result.ExitStatus, strings.TrimSpace(result.Stderr))
+	}
+	return result, nil
+}
+
+// parseCommandResult parses the output of the script in Run: "<exit status> <stdout length>\n<stdout><stderr>".
+func parseCommandResult(output string) (*CommandResult, error) {
+	header, rest, found := strings.Cut(output, "\n")
+	if !found {
+		return nil, fmt.Errorf("unexpected output '%s'", output)
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected output '%s'", output)
+	}
+	status, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(fields[1])
+	if err != nil || length > len(rest) {
+		return nil, fmt.Errorf("unexpected output '%s'", output)
+	}
+	return &CommandResult{
+		ExitStatus: status,
+		Stdout:     rest[:length],
+		Stderr:     rest[length:],
+	}, nil
+}
+
+// shellQuote quotes s as one single-quoted argument of bash.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// tmshQuote quotes s as one word of tmsh if it has other than the safe characters.
+func tmshQuote(s string) string {
+	if regexp.MustCompile(`^[A-Za-z0-9_./:%@,=+-]+$`).MatchString(s) {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,102 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"net/http"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommand_String(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *Command
+		want string
+	}{
+		{
+			name: "save sys config",
+			cmd:  NewTmshCommand("save", "sys", "config").Arg("partitions").Block("p1", "p2"),
+			want: `tmsh -c 'save sys config partitions { p1 p2 }'`,
+		},
+		{
+			name: "quoted tmsh value",
+			cmd:  NewTmshCommand("modify", "sys", "db", "ui.advisory.text", "value", `say "hi"; it's \ok`),
+			want: `tmsh -c 'modify sys db ui.advisory.text value "say \"hi\"; it'\''s \\ok"'`,
+		},
+		{
+			name: "empty tmsh value",
+			cmd:  NewTmshCommand("modify", "sys", "db", "x", "value", ""),
+			want: `tmsh -c 'modify sys db x value ""'`,
+		},
+		{
+			name: "bash",
+			cmd:  NewBashCommand("rm", "-f", "/var/config/rest/downloads/a b;reboot"),
+			want: `'rm' '-f' '/var/config/rest/downloads/a b;reboot'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cmd.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseCommandResult(t *testing.T) {
+	got, err := parseCommandResult("1 4\nout\nerr: 1 4\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&CommandResult{ExitStatus: 1, Stdout: "out\n", Stderr: "err: 1 4\n"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCommandResult() = %+v, want %+v", got, want)
+	}
+	for _, output := range []string{"", "0 1", "x 0\n", "0 9\nshort"} {
+		if _, err := parseCommandResult(output); err == nil {
+			t.Errorf("parseCommandResult(%q) should fail", output)
+		}
+	}
+}
+
+// TestBIGIPContext_Run runs the generated script with the local bash, as /mgmt/tm/util/bash does.
+func TestBIGIPContext_Run(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found")
+	}
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		args := strings.TrimPrefix(body["utilCmdArgs"], "-c ")
+		out, _ := exec.Command("bash", "-c", "bash -c "+args).Output()
+		json.NewEncoder(w).Encode(map[string]string{"commandResult": string(out)})
+	})
+	defer done()
+
+	injection := `'; echo injected; echo "$(id)`
+	result, err := bc.Run(NewBashCommand("printf", "%s", injection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&CommandResult{Stdout: injection}); !reflect.DeepEqual(result, want) {
+		t.Errorf("Run() = %+v, want %+v", result, want)
+	}
+
+	// the trailing newlines are kept.
+	result, err = bc.Run(NewBashCommand("printf", `line\n\n`))
+	if err != nil || result.Stdout != "line\n\n" {
+		t.Errorf("Run() = %+v, %v", result, err)
+	}
+
+	result, err = bc.Run(NewBashCommand("ls", "/nonexistent"))
+	if err == nil || result == nil || result.ExitStatus == 0 || result.Stdout != "" || !strings.Contains(result.Stderr, "nonexistent") {
+		t.Errorf("Run() = %+v, %v", result, err)
+	}
+}
+
+func Test_shellQuote(t *testing.T) {
+	if got := shellQuote(`it's`); got != `'it'\''s'` {
+		t.Errorf("shellQuote() = %s", got)
+	}
+}
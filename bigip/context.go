@@ -0,0 +1,38 @@
+package f5_bigip
+
+import (
+	"context"
+	"time"
+)
+
+// ctx returns the context of bc, context.Background() if it's not set.
+func (bc *BIGIPContext) ctx() context.Context {
+	if bc.Context == nil {
+		return context.Background()
+	}
+	return bc.Context
+}
+
+// detached returns a copy of bc which is not cancelled along with bc, but times out after timeout.
+// Values of the context, like the logger, are kept. It's used for cleaning up after cancellation.
+func (bc *BIGIPContext) detached(timeout time.Duration) (*BIGIPContext, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(valueOnlyContext{bc.ctx()}, timeout)
+	return &BIGIPContext{BIGIP: bc.BIGIP, Context: ctx}, cancel
+}
+
+// valueOnlyContext keeps the values of the parent context, but never be cancelled.
+type valueOnlyContext struct {
+	context.Context
+}
+
+func (valueOnlyContext) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+func (valueOnlyContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (valueOnlyContext) Err() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package f5_bigip
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_DoRestRequests_cancelled(t *testing.T) {
+	deleted := make(chan string, 1)
+	release := make(chan struct{})
+	var once sync.Once
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/mgmt/tm/transaction":
+			w.Write([]byte(`{"transId":1234}`))
+		case r.Method == "DELETE" && r.URL.Path == "/mgmt/tm/transaction/1234":
+			once.Do(func() { deleted <- r.URL.Path })
+			w.Write([]byte(`{}`))
+		default:
+			// hang until the test ends
+			<-release
+		}
+	})
+	defer done()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	bc.Context = ctx
+
+	rr := []RestRequest{
+		{
+			Method:    "POST",
+			ResUri:    "/mgmt/tm/ltm/pool",
+			Kind:      "ltm/pool",
+			ResName:   "pool1",
+			Partition: "p1",
+			Body:      map[string]interface{}{"name": "pool1"},
+			WithTrans: true,
+		},
+	}
+	start := time.Now()
+	err := bc.DoRestRequests(&rr)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("cancellation took too long: %s", time.Since(start))
+	}
+	select {
+	case <-deleted:
+	case <-time.After(5 * time.Second):
+		t.Errorf("abandoned transaction was not deleted")
+	}
+}
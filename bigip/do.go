@@ -0,0 +1,66 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// GetDODeclaration returns the declaration last applied by Declarative Onboarding.
+func (bc *BIGIPContext) GetDODeclaration() (map[string]interface{}, error) {
+	decl, err := bc.requestJSON("GET", doUriPrefix, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retriving do declaration %w", err)
+	}
+	return decl, nil
+}
+
+// DeployDO posts the Declarative Onboarding declaration, and polls the task until it leaves the RUNNING state.
+// BIG-IP may restart services or reboot while onboarding, the retryable failures of polling are tolerated until bc.Context is done.
+func (bc *BIGIPContext) DeployDO(declaration map[string]interface{}) (*DOResult, error) {
+	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(bc.Context)
+
+	switch declaration["class"] {
+	case "DO", "Device":
+	default:
+		return nil, fmt.Errorf("not support, class %v", declaration["class"])
+	}
+	jresp, err := bc.requestJSON("POST", doUriPrefix, declaration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post do declaration: %w", err)
+	}
+	taskId, f := jresp["id"].(string)
+	if !f {
+		return nil, fmt.Errorf("strange.. id not found from %v", jresp)
+	}
+
+	for attempt := 1; ; attempt++ {
+		jresp, err := bc.requestJSON("GET", doUriPrefix+"/task/"+taskId, nil)
+		if err != nil && !utils.NeedRetry(err) {
+			return nil, fmt.Errorf("failed to get status of do task %s: %w", taskId, err)
+		}
+		if err != nil {
+			slog.Debugf("do task %s: management plane unavailable: %s", taskId, err.Error())
+		} else {
+			result := DOResult{}
+			if err := remarshal(jresp["result"], &result); err != nil {
+				return nil, err
+			}
+			switch result.Status {
+			case "RUNNING":
+				slog.Debugf("do task %s is running", taskId)
+			case "OK":
+				return &result, nil
+			default:
+				return &result, fmt.Errorf("do task %s: %s, %s %v", taskId, result.Status, result.Message, result.Errors)
+			}
+		}
+		select {
+		case <-bc.ctx().Done():
+			return nil, fmt.Errorf("waiting for do task %s aborted: %w", taskId, bc.ctx().Err())
+		case <-time.After(taskPollPolicy.backoff(attempt)):
+		}
+	}
+}
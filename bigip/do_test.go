@@ -0,0 +1,43 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestBIGIPContext_DeployDO(t *testing.T) {
+	polled := 0
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/mgmt/shared/declarative-onboarding":
+			w.WriteHeader(202)
+			fmt.Fprint(w, `{"id":"d0","result":{"class":"Result","code":202,"status":"RUNNING","message":"processing"}}`)
+		case r.URL.Path == "/mgmt/shared/declarative-onboarding/task/d0":
+			polled++
+			switch polled {
+			case 1:
+				fmt.Fprint(w, `{"id":"d0","result":{"class":"Result","code":202,"status":"RUNNING","message":"processing"}}`)
+			case 2:
+				// restnoded is restarting
+				w.WriteHeader(503)
+			case 3:
+				w.WriteHeader(404)
+				fmt.Fprint(w, `{"code":404,"message":"Public URI path not registered: /declarative-onboarding/task/d0"}`)
+			default:
+				fmt.Fprint(w, `{"id":"d0","result":{"class":"Result","code":200,"status":"OK","message":"success"}}`)
+			}
+		default:
+			w.WriteHeader(404)
+		}
+	})
+	defer done()
+
+	result, err := bc.DeployDO(map[string]interface{}{"class": "Device", "schemaVersion": "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "OK" || result.Code != 200 || polled != 4 {
+		t.Errorf("result = %+v after %d polls", result, polled)
+	}
+}
@@ -0,0 +1,139 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// Download writes /var/config/rest/downloads/<name> on BIG-IP to w, in ranged chunks.
+// It returns the size downloaded so far, which is the Offset to resume with after a failure. opts can be nil.
+func (bc *BIGIPContext) Download(name string, w io.Writer, opts *DownloadOptions) (int64, error) {
+	return bc.download(downloadUriPrefix+name, w, opts)
+}
+
+// DownloadUCS writes the UCS archive /var/local/ucs/<name> on BIG-IP to w, the same as Download.
+func (bc *BIGIPContext) DownloadUCS(name string, w io.Writer, opts *DownloadOptions) (int64, error) {
+	return bc.download(ucsDownloadUriPrefix+name, w, opts)
+}
+
+// DownloadImage writes the software image /shared/images/<name> on BIG-IP to w, the same as Download.
+func (bc *BIGIPContext) DownloadImage(name string, w io.Writer, opts *DownloadOptions) (int64, error) {
+	return bc.download(imageDownloadUriPrefix+name, w, opts)
+}
+
+// download reads the file-transfer endpoint uri chunk by chunk, a chunk is written to w only when it's received entirely.
+func (bc *BIGIPContext) download(uri string, w io.Writer, opts *DownloadOptions) (int64, error) {
+	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(bc.Context)
+
+	o := DownloadOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultDownloadChunkSize
+	}
+	if o.Retries <= 0 {
+		o.Retries = DefaultDownloadChunkRetries
+	}
+	if o.Offset < 0 {
+		return 0, fmt.Errorf("invalid offset %d to download %s", o.Offset, uri)
+	}
+
+	// chunks are retried here, not by each request.
+	cbc := *bc
+	cbc.retry = RetryPolicy{}
+	policy := RetryPolicy{MaxAttempts: o.Retries, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	start, total := o.Offset, int64(-1)
+	for total < 0 || start < total {
+		end := start + o.ChunkSize - 1
+		var chunk []byte
+		size := int64(-1)
+		err := bc.retryWith(policy, fmt.Sprintf("download %s %d-%d", uri, start, end), func() error {
+			var err error
+			chunk, size, err = cbc.downloadChunk(uri, start, end)
+			return err
+		})
+		if err != nil {
+			return start, err
+		}
+		if o.MaxSize > 0 && size > o.MaxSize {
+			return start, fmt.Errorf("size %d of %s exceeds the limit %d", size, uri, o.MaxSize)
+		}
+		if total >= 0 && size != total {
+			return start, fmt.Errorf("size of %s changed from %d to %d while downloading", uri, total, size)
+		}
+		total = size
+		if start >= total {
+			break
+		}
+		if len(chunk) == 0 {
+			return start, fmt.Errorf("empty chunk at %d of %s", start, uri)
+		}
+		if start+int64(len(chunk)) > total {
+			chunk = chunk[:total-start]
+		}
+		n, err := w.Write(chunk)
+		start += int64(n)
+		if err != nil {
+			return start, fmt.Errorf("failed to write content at %d of %s: %w", start, uri, err)
+		}
+		if o.Progress != nil {
+			o.Progress(start, total)
+		}
+	}
+	slog.Debugf("downloaded %d bytes from %s", start, uri)
+	return start, nil
+}
+
+// downloadChunk gets the bytes start-end of uri, with the total size of the file parsed from the response.
+func (bc *BIGIPContext) downloadChunk(uri string, start, end int64) ([]byte, int64, error) {
+	url := bc.URL + uri
+	method := "GET"
+	headers := map[string]string{
+		"Content-Type":  "application/octet-stream",
+		"Content-Range": fmt.Sprintf("%d-%d/0", start, end),
+	}
+
+	code, header, resp, err := httpRequestWithHeaders(bc, url, method, "", headers)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := assertBigipResp(method, url, code, resp); err != nil {
+		return nil, 0, fmt.Errorf("error downloading %w", err)
+	}
+
+	size, err := parseContentRangeSize(header)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error downloading %s: %s", url, err.Error())
+	}
+	if size < 0 {
+		// the whole file is responded without ranges.
+		if start != 0 {
+			return nil, 0, fmt.Errorf("error downloading %s: ranges not supported", url)
+		}
+		size = int64(len(resp))
+	}
+	return resp, size, nil
+}
+
+// parseContentRangeSize returns the total size in the Content-Range header like "0-1048575/5242880",
+// or -1 if the header is absent.
+func parseContentRangeSize(header http.Header) (int64, error) {
+	cr := header.Get("Content-Range")
+	if cr == "" {
+		return -1, nil
+	}
+	m := regexp.MustCompile(`^(?:bytes )?(?:\d+-\d+|\*)/(\d+)$`).FindStringSubmatch(cr)
+	if m == nil {
+		return 0, fmt.Errorf("invalid Content-Range '%s'", cr)
+	}
+	return strconv.ParseInt(m[1], 10, 64)
+}
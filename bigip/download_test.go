@@ -0,0 +1,66 @@
+package f5_bigip
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBIGIPContext_Download(t *testing.T) {
+	content := "0123456789"
+	failed := false
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mgmt/shared/file-transfer/ucs-downloads/backup.ucs" {
+			w.WriteHeader(404)
+			return
+		}
+		var start, end int
+		fmt.Sscanf(r.Header.Get("Content-Range"), "%d-%d/0", &start, &end)
+		if start == 4 && !failed {
+			failed = true
+			w.WriteHeader(503)
+			return
+		}
+		if end >= len(content) {
+			end = len(content) - 1
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%d", start, end, len(content)))
+		w.WriteHeader(206)
+		w.Write([]byte(content[start : end+1]))
+	})
+	defer done()
+
+	var buf bytes.Buffer
+	progress := []int64{}
+	n, err := bc.DownloadUCS("backup.ucs", &buf, &DownloadOptions{
+		ChunkSize: 4,
+		Progress:  func(downloaded, total int64) { progress = append(progress, downloaded) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 || buf.String() != content {
+		t.Errorf("downloaded %d bytes '%s', want '%s'", n, buf.String(), content)
+	}
+	if want := []int64{4, 8, 10}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+
+	buf.Reset()
+	n, err = bc.DownloadUCS("backup.ucs", &buf, &DownloadOptions{ChunkSize: 4, Offset: 6})
+	if err != nil || n != 10 || buf.String() != content[6:] {
+		t.Errorf("resumed downloading: %d bytes '%s', err %v", n, buf.String(), err)
+	}
+
+	buf.Reset()
+	n, err = bc.DownloadUCS("backup.ucs", &buf, &DownloadOptions{ChunkSize: 4, MaxSize: 8})
+	if err == nil || n != 0 || buf.Len() != 0 {
+		t.Errorf("size limit should be enforced before writing: %d bytes, err %v", n, err)
+	}
+
+	if _, err := bc.Download("notexist", &buf, nil); !IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+func (e *BigipError) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("%d, %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("%s %s: %d, %s", e.Method, e.URI, e.StatusCode, e.Body)
+}
+
+// NeedRetry makes utils.NeedRetry work with BigipError.
+func (e *BigipError) NeedRetry() bool {
+	return e.Retryable
+}
+
+// newBigipError parses the iControl error response and classifies if it's retryable.
+func newBigipError(statusCode int, resp []byte) *BigipError {
+	e := BigipError{}
+	// the body may be html or truncated, leave the fields empty in that case.
+	json.Unmarshal(resp, &e)
+	e.StatusCode = statusCode
+	e.Body = string(resp)
+
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusServiceUnavailable, http.StatusInternalServerError:
+		e.Retryable = true
+	case http.StatusNotFound:
+		for _, p := range []string{
+			".*URI path .* not registered.*",
+			".*Public URI path not registered: .*",
+		} {
+			if matched, err := regexp.Match(p, resp); err == nil && matched {
+				e.Retryable = true
+			}
+		}
+	}
+	return &e
+}
+
+// assertBigipResp does the same as assertBigipResp20X,
+// with the request method and uri recorded into the returned BigipError.
+func assertBigipResp(method, url string, statusCode int, resp []byte) error {
+	err := assertBigipResp20X(statusCode, resp)
+	var berr *BigipError
+	if errors.As(err, &berr) {
+		berr.Method = method
+		if i := strings.Index(url, "/mgmt/"); i >= 0 {
+			berr.URI = url[i:]
+		} else {
+			berr.URI = url
+		}
+	}
+	return err
+}
+
+// IsNotFound tells if err is caused by a resource not found on BIG-IP.
+func IsNotFound(err error) bool {
+	var berr *BigipError
+	return errors.As(err, &berr) && berr.StatusCode == http.StatusNotFound && !berr.Retryable
+}
+
+// IsConflict tells if err is caused by creating a resource that already exists.
+func IsConflict(err error) bool {
+	var berr *BigipError
+	return errors.As(err, &berr) && berr.StatusCode == http.StatusConflict
+}
+
+// IsMcpdUnavailable tells if err is caused by mcpd being restarted or not ready,
+// the call can be retried after mcpd gets back.
+func IsMcpdUnavailable(err error) bool {
+	var berr *BigipError
+	if !errors.As(err, &berr) {
+		return false
+	}
+	// 500: {"code":500,"message":"The connection to mcpd has been lost, try again.","errorStack":[],"apiError":32768001}
+	return berr.APIError == 32768001 || strings.Contains(berr.Message, "connection to mcpd")
+}
+
+// isFolderNotFound tells if err is caused by the partition or folder not found.
+func isFolderNotFound(err error) bool {
+	var berr *BigipError
+	return IsNotFound(err) && errors.As(err, &berr) &&
+		regexp.MustCompile(`The requested folder (.*) was not found.`).MatchString(berr.Message)
+}
+
+func (e *TransactionError) Error() string {
+	if e.Request == nil {
+		return fmt.Sprintf("transaction %.f failed: %s", e.TransId, e.Message)
+	}
+	r := e.Request
+	return fmt.Sprintf("transaction %.f failed at command %d, %s %s %s: %s",
+		e.TransId, e.EvalOrder, r.Method, r.Kind, "/"+uriname(r.Partition, r.Subfolder, r.ResName), e.Message)
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *BatchError) Error() string {
+	done := 0
+	for i, r := range e.Results {
+		if r.Done {
+			done++
+		}
+		if r.Err != nil {
+			return fmt.Sprintf("batch %d of %d failed, %d committed: %s", i+1, len(e.Results), done, r.Err.Error())
+		}
+	}
+	return fmt.Sprintf("%d of %d batches committed", done, len(e.Results))
+}
+
+// Unwrap returns the error of the failed batch.
+func (e *BatchError) Unwrap() error {
+	for _, r := range e.Results {
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
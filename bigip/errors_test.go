@@ -0,0 +1,85 @@
+package f5_bigip
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+func Test_assertBigipResp(t *testing.T) {
+	resp := []byte(`{"code":404,"message":"01020036:3: The requested Pool (/Common/my-pool) was not found.","errorStack":[],"apiError":3}`)
+	err := assertBigipResp("GET", "https://1.2.3.4/mgmt/tm/ltm/pool/~Common~my-pool", 404, resp)
+
+	wrapped := fmt.Errorf("error checking ltm/pool %w", err)
+	var berr *BigipError
+	if !errors.As(wrapped, &berr) {
+		t.Fatalf("BigipError not found from %v", wrapped)
+	}
+	if berr.StatusCode != 404 || berr.Code != 404 || berr.APIError != 3 ||
+		berr.Message != "01020036:3: The requested Pool (/Common/my-pool) was not found." ||
+		berr.Method != "GET" || berr.URI != "/mgmt/tm/ltm/pool/~Common~my-pool" {
+		t.Errorf("unexpected BigipError: %#v", berr)
+	}
+	if !IsNotFound(wrapped) || IsConflict(wrapped) || IsMcpdUnavailable(wrapped) || utils.NeedRetry(wrapped) {
+		t.Errorf("wrong classification of %v", wrapped)
+	}
+}
+
+func Test_errorClassification(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		notfound bool
+		conflict bool
+		mcpd     bool
+		folder   bool
+	}{
+		{
+			name:     "folder not found",
+			err:      assertBigipResp20X(404, []byte(`{"code":404,"message":"01020036:3: The requested folder (/p1) was not found.","errorStack":[],"apiError":3}`)),
+			notfound: true,
+			folder:   true,
+		},
+		{
+			name: "uri not registered",
+			err:  assertBigipResp20X(404, []byte(`{"code":404,"message":"Public URI path not registered: /tm/ltm/pool/?Common?my-pool","referer":"10.250.64.100","restOperationId":39168,"kind":":resterrorresponse"}`)),
+		},
+		{
+			name:     "conflict",
+			err:      assertBigipResp20X(409, []byte(`{"code":409,"message":"01020066:3: The requested Pool (/Common/my-pool) already exists in partition Common.","errorStack":[],"apiError":3}`)),
+			conflict: true,
+		},
+		{
+			name: "mcpd lost",
+			err:  assertBigipResp20X(500, []byte(`{"code":500,"message":"The connection to mcpd has been lost, try again.","errorStack":[],"apiError":32768001}`)),
+			mcpd: true,
+		},
+		{
+			name: "html response",
+			err:  assertBigipResp20X(503, []byte("long html response..: Configuration Utility restarting...")),
+		},
+		{
+			name:     "merged",
+			err:      utils.MergeErrors([]error{fmt.Errorf("plain"), assertBigipResp20X(409, []byte(`{"code":409}`))}),
+			conflict: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNotFound(tt.err); got != tt.notfound {
+				t.Errorf("IsNotFound() = %v, want %v", got, tt.notfound)
+			}
+			if got := IsConflict(tt.err); got != tt.conflict {
+				t.Errorf("IsConflict() = %v, want %v", got, tt.conflict)
+			}
+			if got := IsMcpdUnavailable(tt.err); got != tt.mcpd {
+				t.Errorf("IsMcpdUnavailable() = %v, want %v", got, tt.mcpd)
+			}
+			if got := isFolderNotFound(tt.err); got != tt.folder {
+				t.Errorf("isFolderNotFound() = %v, want %v", got, tt.folder)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// ExportPartition walks the partition on BIG-IP, including subfolders, for the kinds in ResOrder, and returns
+// the config in the format of GenRestRequests, so that the hand-made resources can be adopted:
+//
+//	{
+//		"<folder name>": {
+//			"<kind>/<resource name>": {
+//				"resource property key": "resource property value",
+//			}
+//		}
+//	}
+//
+// The read-only and runtime properties, like selfLink, generation, fullPath, kind and *Reference links, are stripped.
+// The subcollections, like the members of pools and the profiles of virtuals, are exported as members and profiles,
+// which are compared with WithMinimalPatch only, so the config re-imported changes nothing with it.
+// The uploaded files and the kinds under other resources, like net/fdb/tunnel/<tunnel>/records, are not exported.
+func (bc *BIGIPContext) ExportPartition(partition string) (map[string]interface{}, error) {
+	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(bc.Context)
+
+	kinds, err := bc.exportKinds()
+	if err != nil {
+		return nil, err
+	}
+	existings, err := bc.existingResources(partition, kinds, true)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := map[string]interface{}{
+		"": map[string]interface{}{},
+	}
+	for _, kind := range kinds {
+		for _, item := range (*existings)[kind] {
+			props, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if kind == "sys/folder" {
+				// folders are created along with the keys of config.
+				if folder := exportedFolder(partition, props); folder != "" {
+					if _, f := cfg[folder]; !f {
+						cfg[folder] = map[string]interface{}{}
+					}
+				}
+				continue
+			}
+			folder, _ := props["subPath"].(string)
+			name, _ := props["name"].(string)
+			if _, f := cfg[folder]; !f {
+				cfg[folder] = map[string]interface{}{}
+			}
+			cfg[folder].(map[string]interface{})[kind+"/"+name] = exportedProps(props)
+		}
+	}
+	slog.Debugf("exported %d folders of partition %s", len(cfg), partition)
+	return cfg, nil
+}
+
+// exportKinds returns the kinds in ResOrder, with the patterns like ltm/monitor/\w+ resolved
+// by the organizing collections on BIG-IP, like ltm/monitor.
+func (bc *BIGIPContext) exportKinds() ([]string, error) {
+	slog := utils.LogFromContext(bc.Context)
+
+	kinds := []string{}
+	collections := map[string][]string{}
+	for _, k := range ResOrder {
+		pattern := strings.TrimSuffix(k, "$")
+		if strings.HasPrefix(pattern, "shared/") {
+			continue
+		}
+		if regexp.QuoteMeta(pattern) == pattern {
+			kinds = append(kinds, pattern)
+			continue
+		}
+		parent := pattern[:strings.IndexAny(pattern, `\.+*?()|[]{}^$`)]
+		parent = strings.TrimSuffix(parent[:strings.LastIndex(parent, "/")+1], "/")
+		if _, f := collections[parent]; !f {
+			subs, err := bc.organizedKinds(parent)
+			if err != nil {
+				return nil, err
+			}
+			collections[parent] = subs
+		}
+		rex := regexp.MustCompile("^(?:" + pattern + ")$")
+		for _, sub := range collections[parent] {
+			if rex.MatchString(sub) {
+				kinds = append(kinds, sub)
+			}
+		}
+		slog.Tracef("kinds of %s: %v", k, collections[parent])
+	}
+	return utils.Unified(kinds), nil
+}
+
+// organizedKinds returns the kinds referred by the organizing collection, like ltm/monitor/http of ltm/monitor.
+func (bc *BIGIPContext) organizedKinds(collection string) ([]string, error) {
+	kinds := []string{}
+	items, err := bc.ListAll(collection, nil)
+	if err != nil {
+		if IsNotFound(err) {
+			return kinds, nil
+		}
+		return nil, fmt.Errorf("failed to list kinds of %s: %w", collection, err)
+	}
+	for _, item := range items {
+		ref, _ := item["reference"].(map[string]interface{})
+		link, _ := ref["link"].(string)
+		if i := strings.Index(link, TmUriPrefix+"/"); i >= 0 {
+			kind := strings.Split(link[i+len(TmUriPrefix)+1:], "?")[0]
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
+// exportedFolder returns the subfolder path of the partition, like "f1" of "/p1/f1".
+func exportedFolder(partition string, props map[string]interface{}) string {
+	if fullPath, ok := props["fullPath"].(string); ok {
+		if !strings.HasPrefix(fullPath, "/"+partition+"/") {
+			return ""
+		}
+		return strings.TrimPrefix(fullPath, "/"+partition+"/")
+	}
+	name, _ := props["name"].(string)
+	return name
+}
+
+// exportedProps returns a copy of props without the read-only and runtime properties.
+// The nested objects are kept as they are, they're compared as a whole when deploying.
+func exportedProps(props map[string]interface{}) map[string]interface{} {
+	body := map[string]interface{}{}
+	for k, v := range props {
+		switch k {
+		case "kind", "selfLink", "generation", "fullPath", "partition", "subPath",
+			"creationTime", "lastModifiedTime", "vsIndex":
+			continue
+		}
+		if strings.HasSuffix(k, "Reference") {
+			continue
+		}
+		// the items of the subcollections inlined.
+		if ref, ok := props[k+"Reference"].(map[string]interface{}); ok && ref["isSubcollection"] == true {
+			if items, ok := v.([]interface{}); ok {
+				exported := []interface{}{}
+				for _, item := range items {
+					if m, ok := item.(map[string]interface{}); ok {
+						item = exportedProps(m)
+					}
+					exported = append(exported, item)
+				}
+				v = exported
+			}
+		}
+		body[k] = v
+	}
+	return body
+}
@@ -0,0 +1,85 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBIGIPContext_ExportPartition(t *testing.T) {
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("$filter") != "" && r.URL.Query().Get("expandSubcollections") != "true" && r.URL.Path != "/mgmt/tm/sys/folder" {
+			t.Errorf("listing %s without subcollections", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/mgmt/tm/ltm/monitor":
+			fmt.Fprint(w, `{"items":[
+				{"reference":{"link":"https://localhost/mgmt/tm/ltm/monitor/http?ver=16.1.0"}},
+				{"reference":{"link":"https://localhost/mgmt/tm/ltm/monitor/tcp?ver=16.1.0"}}
+			]}`)
+		case "/mgmt/tm/sys/folder":
+			fmt.Fprint(w, `{"items":[{"kind":"tm:sys:folder:folderstate","name":"f1","partition":"p1","fullPath":"/p1/f1","generation":1}]}`)
+		case "/mgmt/tm/ltm/monitor/http":
+			fmt.Fprint(w, `{"items":[{"kind":"tm:ltm:monitor:http:httpstate","name":"mon1","partition":"p1","fullPath":"/p1/mon1",
+				"selfLink":"https://localhost/mgmt/tm/ltm/monitor/http/~p1~mon1?ver=16.1.0","generation":3,
+				"interval":5,"send":"GET /\r\n","defaultsFrom":"/Common/http"}]}`)
+		case "/mgmt/tm/ltm/pool":
+			fmt.Fprint(w, `{"items":[{"kind":"tm:ltm:pool:poolstate","name":"pool1","partition":"p1","subPath":"f1","fullPath":"/p1/f1/pool1",
+				"generation":4,"loadBalancingMode":"round-robin","monitor":"/p1/mon1",
+				"membersReference":{"link":"https://localhost/mgmt/tm/ltm/pool/~p1~f1~pool1/members?ver=16.1.0","isSubcollection":true,
+					"items":[{"kind":"tm:ltm:pool:members:membersstate","name":"192.0.2.1:80","partition":"p1","fullPath":"/p1/192.0.2.1:80",
+						"generation":4,"selfLink":"https://localhost/mgmt/tm/ltm/pool/~p1~f1~pool1/members/~p1~192.0.2.1:80?ver=16.1.0",
+						"address":"192.0.2.1","ratio":1}]}}]}`)
+		case "/mgmt/tm/ltm/virtual":
+			fmt.Fprint(w, `{"items":[{"kind":"tm:ltm:virtual:virtualstate","name":"vs1","partition":"p1","fullPath":"/p1/vs1","generation":5,
+				"creationTime":"2023-01-31T10:20:30Z","lastModifiedTime":"2023-01-31T10:20:30Z","vsIndex":2,
+				"destination":"/p1/192.0.2.10:80","pool":"/p1/f1/pool1",
+				"profilesReference":{"link":"https://localhost/mgmt/tm/ltm/virtual/~p1~vs1/profiles?ver=16.1.0","isSubcollection":true,
+					"items":[{"kind":"tm:ltm:virtual:profiles:profilesstate","name":"http","partition":"Common","fullPath":"/Common/http",
+						"generation":5,"context":"all",
+						"nameReference":{"link":"https://localhost/mgmt/tm/ltm/profile/http/~Common~http?ver=16.1.0"}}]}}]}`)
+		default:
+			w.WriteHeader(404)
+			fmt.Fprintf(w, `{"code":404,"message":"Object not found - %s"}`, r.URL.Path)
+		}
+	}, WithMinimalPatch())
+	defer done()
+
+	cfg, err := bc.ExportPartition("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"": map[string]interface{}{
+			"ltm/monitor/http/mon1": map[string]interface{}{
+				"name": "mon1", "interval": float64(5), "send": "GET /\r\n", "defaultsFrom": "/Common/http",
+			},
+			"ltm/virtual/vs1": map[string]interface{}{
+				"name": "vs1", "destination": "/p1/192.0.2.10:80", "pool": "/p1/f1/pool1",
+				"profiles": []interface{}{map[string]interface{}{"name": "http", "context": "all"}},
+			},
+		},
+		"f1": map[string]interface{}{
+			"ltm/pool/pool1": map[string]interface{}{
+				"name": "pool1", "loadBalancingMode": "round-robin", "monitor": "/p1/mon1",
+				"members": []interface{}{map[string]interface{}{"name": "192.0.2.1:80", "address": "192.0.2.1", "ratio": float64(1)}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("ExportPartition() = %v, want %v", cfg, want)
+	}
+
+	// re-importing the exported config with minimal patch changes nothing.
+	plan, _, err := bc.GenPlan("p1", nil, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() {
+		t.Errorf("plan of re-importing is not empty: %s", plan)
+	}
+}
@@ -0,0 +1,181 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+func NewListQuery() *ListQuery {
+	return &ListQuery{}
+}
+
+// Filter adds "<field> eq <value>" to $filter, multiple filters are joined with "and".
+func (q *ListQuery) Filter(field, value string) *ListQuery {
+	q.filters = append(q.filters, fmt.Sprintf("%s eq %s", field, value))
+	return q
+}
+
+// Select limits the properties of the returned items.
+func (q *ListQuery) Select(fields ...string) *ListQuery {
+	q.selects = append(q.selects, fields...)
+	return q
+}
+
+// Top sets the page size, the pages are followed automatically by List and Iterate.
+func (q *ListQuery) Top(n int) *ListQuery {
+	q.top = n
+	return q
+}
+
+// Skip skips the first n items.
+func (q *ListQuery) Skip(n int) *ListQuery {
+	q.skip = n
+	return q
+}
+
+// ExpandSubcollections embeds the subcollections, i.e. pool members, into the items.
+func (q *ListQuery) ExpandSubcollections() *ListQuery {
+	q.expand = true
+	return q
+}
+
+// Encode returns the query string, without the leading '?'.
+func (q *ListQuery) Encode() string {
+	if q == nil {
+		return ""
+	}
+	params := []string{}
+	if len(q.filters) > 0 {
+		params = append(params, "$filter="+url.QueryEscape(strings.Join(q.filters, " and ")))
+	}
+	if len(q.selects) > 0 {
+		escaped := []string{}
+		for _, s := range q.selects {
+			escaped = append(escaped, url.QueryEscape(s))
+		}
+		params = append(params, "$select="+strings.Join(escaped, ","))
+	}
+	if q.top > 0 {
+		params = append(params, fmt.Sprintf("$top=%d", q.top))
+	}
+	if q.skip > 0 {
+		params = append(params, fmt.Sprintf("$skip=%d", q.skip))
+	}
+	if q.expand {
+		params = append(params, "expandSubcollections=true")
+	}
+	return strings.Join(params, "&")
+}
+
+// List calls fn for each item of /mgmt/tm/<kind> matching query, page by page.
+// Listing stops at the first error returned by fn, which is returned by List, except ErrStopListing.
+func (bc *BIGIPContext) List(kind string, query *ListQuery, fn func(item map[string]interface{}) error) error {
+	defer utils.TimeItToPrometheus()()
+
+	it := bc.Iterate(kind, query)
+	for it.Next() {
+		if err := fn(it.Item()); errors.Is(err, ErrStopListing) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// ListAll returns all the items of /mgmt/tm/<kind> matching query.
+func (bc *BIGIPContext) ListAll(kind string, query *ListQuery) ([]map[string]interface{}, error) {
+	items := []map[string]interface{}{}
+	err := bc.List(kind, query, func(item map[string]interface{}) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+// Iterate returns an iterator over the items of /mgmt/tm/<kind> matching query,
+// the next page is requested only when the current one is consumed:
+//
+//	it := bc.Iterate("ltm/pool", NewListQuery().Top(100))
+//	for it.Next() {
+//		item := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+func (bc *BIGIPContext) Iterate(kind string, query *ListQuery) *ListIterator {
+	u := bc.URL + "/mgmt/tm/" + kind
+	if qs := query.Encode(); qs != "" {
+		u += "?" + qs
+	}
+	return &ListIterator{bc: bc, kind: kind, next: u}
+}
+
+// Next moves to the next item, it returns false when there are no more items or an error happens.
+func (it *ListIterator) Next() bool {
+	for len(it.items) == 0 {
+		if it.err != nil || it.next == "" {
+			return false
+		}
+		it.fetch()
+	}
+	raw := it.items[0]
+	it.items = it.items[1:]
+	item, ok := raw.(map[string]interface{})
+	if !ok {
+		it.err = fmt.Errorf("unexpected item type %T when listing %s", raw, it.kind)
+		return false
+	}
+	it.item = item
+	return true
+}
+
+// Item returns the current item.
+func (it *ListIterator) Item() map[string]interface{} {
+	return it.item
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *ListIterator) Err() error {
+	return it.err
+}
+
+func (it *ListIterator) fetch() {
+	bc := it.bc
+	method := "GET"
+	url := it.next
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	it.next = ""
+
+	code, resp, err := httpRequest(bc, url, method, "", headers)
+	if err != nil {
+		it.err = err
+		return
+	}
+	if err := assertBigipResp(method, url, code, resp); err != nil {
+		it.err = fmt.Errorf("error retriving %s %w", it.kind, err)
+		return
+	}
+
+	var page struct {
+		Items    []interface{} `json:"items"`
+		NextLink string        `json:"nextLink"`
+	}
+	if err := json.Unmarshal(resp, &page); err != nil {
+		it.err = err
+		return
+	}
+	it.items = page.Items
+	if page.NextLink != "" {
+		// nextLink is given as https://localhost/mgmt/tm/...
+		if i := strings.Index(page.NextLink, "/mgmt/"); i >= 0 {
+			it.next = bc.URL + page.NextLink[i:]
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestListQuery_Encode(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *ListQuery
+		want  string
+	}{
+		{"nil", nil, ""},
+		{"empty", NewListQuery(), ""},
+		{"partition", NewListQuery().Filter("partition", "p1"), "$filter=partition+eq+p1"},
+		{
+			name:  "all",
+			query: NewListQuery().Filter("partition", "p1").Filter("subPath", "f1").Select("name", "fullPath").Top(10).Skip(20).ExpandSubcollections(),
+			want:  "$filter=partition+eq+p1+and+subPath+eq+f1&$select=name,fullPath&$top=10&$skip=20&expandSubcollections=true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.Encode(); got != tt.want {
+				t.Errorf("Encode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBIGIPContext_List(t *testing.T) {
+	total := 7
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		top, _ := strconv.Atoi(r.URL.Query().Get("$top"))
+		skip, _ := strconv.Atoi(r.URL.Query().Get("$skip"))
+		items := ""
+		for i := skip; i < skip+top && i < total; i++ {
+			if items != "" {
+				items += ","
+			}
+			items += fmt.Sprintf(`{"name":"pool%d"}`, i)
+		}
+		next := ""
+		if skip+top < total {
+			next = fmt.Sprintf(`"nextLink":"https://localhost/mgmt/tm/ltm/pool?$top=%d&$skip=%d&ver=16.1.0",`, top, skip+top)
+		}
+		fmt.Fprintf(w, `{%s"items":[%s]}`, next, items)
+	})
+	defer done()
+
+	items, err := bc.ListAll("ltm/pool", NewListQuery().Top(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != total {
+		t.Fatalf("got %d items, want %d", len(items), total)
+	}
+	for i, item := range items {
+		if item["name"] != fmt.Sprintf("pool%d", i) {
+			t.Errorf("item %d = %v", i, item)
+		}
+	}
+
+	count := 0
+	err = bc.List("ltm/pool", NewListQuery().Top(3), func(item map[string]interface{}) error {
+		count++
+		if count == 4 {
+			return fmt.Errorf("enough: %w", ErrStopListing)
+		}
+		return nil
+	})
+	if err != nil || count != 4 {
+		t.Errorf("stop listing: count = %d, err = %v", count, err)
+	}
+}
+
+func TestBIGIPContext_GetExistingResources(t *testing.T) {
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("$top") != fmt.Sprint(DefaultListPageSize) {
+			t.Errorf("listing without page size: %s", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `{"items":[{"name":"pool1","subPath":"f1"},{"selfLink":"https://localhost/mgmt/tm/ltm/pool/noname"}]}`)
+	})
+	defer done()
+
+	existings, err := bc.GetExistingResources("p1", []string{"ltm/pool"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pools := (*existings)["ltm/pool"]; len(pools) != 1 || pools["p1/f1/pool1"] == nil {
+		t.Errorf("unexpected existing pools: %v", pools)
+	}
+}
@@ -0,0 +1,129 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// PoolMembers returns the typed members of the ltm pool.
+func (bc *BIGIPContext) PoolMembers(poolname, partition, subfolder string) ([]PoolMember, error) {
+	mbs, err := bc.Members(poolname, partition, subfolder)
+	if err != nil {
+		return nil, err
+	}
+	members := []PoolMember{}
+	if err := remarshal(mbs, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// EnablePoolMember enables the member of the pool, and marks it up unless the monitors say no.
+// member is like "10.0.0.1:80" in the partition of the pool, or the full path like "/Common/10.0.0.1:80".
+func (bc *BIGIPContext) EnablePoolMember(poolname, member, partition, subfolder string) error {
+	return bc.setPoolMemberState(poolname, member, partition, subfolder, "user-enabled", "user-up")
+}
+
+// DisablePoolMember disables the member of the pool gracefully:
+// new connections are refused, while the existing connections and persistent sessions continue.
+// The member forced offline before is brought back to disabled.
+func (bc *BIGIPContext) DisablePoolMember(poolname, member, partition, subfolder string) error {
+	return bc.setPoolMemberState(poolname, member, partition, subfolder, "user-disabled", "user-up")
+}
+
+// ForceOfflinePoolMember forces the member of the pool offline: only the active connections continue.
+func (bc *BIGIPContext) ForceOfflinePoolMember(poolname, member, partition, subfolder string) error {
+	return bc.setPoolMemberState(poolname, member, partition, subfolder, "user-disabled", "user-down")
+}
+
+// EnableNode enables the node, which affects the members of it in all pools.
+func (bc *BIGIPContext) EnableNode(name, partition string) error {
+	return bc.setNodeState(name, partition, "user-enabled", "user-up")
+}
+
+// DisableNode disables the node gracefully, which affects the members of it in all pools.
+// The node forced offline before is brought back to disabled.
+func (bc *BIGIPContext) DisableNode(name, partition string) error {
+	return bc.setNodeState(name, partition, "user-disabled", "user-up")
+}
+
+// ForceOfflineNode forces the node offline, which affects the members of it in all pools.
+func (bc *BIGIPContext) ForceOfflineNode(name, partition string) error {
+	return bc.setNodeState(name, partition, "user-disabled", "user-down")
+}
+
+// WaitPoolMemberDrained polls the stats of the member until it has no connections, or bc.Context is done.
+// It's usually called after DisablePoolMember, before the member is removed for maintenance.
+func (bc *BIGIPContext) WaitPoolMemberDrained(poolname, member, partition, subfolder string) error {
+	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(bc.Context)
+
+	fullPath := memberFullPath(member, partition)
+	for attempt := 1; ; attempt++ {
+		mstats, err := bc.PoolMemberStats(poolname, partition, subfolder)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, m := range mstats {
+			if m.FullPath != fullPath {
+				continue
+			}
+			found = true
+			if m.CurConns == 0 {
+				return nil
+			}
+			slog.Debugf("waiting for %d connections of %s to drain", m.CurConns, fullPath)
+		}
+		if !found {
+			return fmt.Errorf("member %s not found in pool %s", fullPath, poolname)
+		}
+		select {
+		case <-bc.ctx().Done():
+			return fmt.Errorf("waiting for %s to drain aborted: %w", fullPath, bc.ctx().Err())
+		case <-time.After(taskPollPolicy.backoff(attempt)):
+		}
+	}
+}
+
+func (bc *BIGIPContext) setPoolMemberState(poolname, member, partition, subfolder, session, state string) error {
+	defer utils.TimeItToPrometheus()()
+
+	mp, mn := splitFullPath(memberFullPath(member, partition))
+	uri := fmt.Sprintf("/mgmt/tm/ltm/pool/%s/members/%s", utils.Refname(partition, subfolder, poolname), utils.Refname(strings.ReplaceAll(mp, "/", "~"), "", mn))
+	if _, err := bc.requestJSON("PATCH", uri, stateBody(session, state)); err != nil {
+		return fmt.Errorf("failed to set %s of pool %s to %s %s: %w", member, poolname, session, state, err)
+	}
+	return nil
+}
+
+func (bc *BIGIPContext) setNodeState(name, partition, session, state string) error {
+	defer utils.TimeItToPrometheus()()
+
+	uri := "/mgmt/tm/ltm/node/" + utils.Refname(partition, "", name)
+	if _, err := bc.requestJSON("PATCH", uri, stateBody(session, state)); err != nil {
+		return fmt.Errorf("failed to set node %s to %s %s: %w", name, session, state, err)
+	}
+	return nil
+}
+
+func stateBody(session, state string) map[string]interface{} {
+	return map[string]interface{}{"session": session, "state": state}
+}
+
+func memberFullPath(member, partition string) string {
+	if strings.HasPrefix(member, "/") {
+		return member
+	}
+	return "/" + partition + "/" + member
+}
+
+// splitFullPath splits "/Common/10.0.0.1:80" into "Common" and "10.0.0.1:80",
+// the folder is kept in the first part, like "Common/f1".
+func splitFullPath(fullPath string) (string, string) {
+	i := strings.LastIndex(fullPath, "/")
+	return strings.Trim(fullPath[:i], "/"), fullPath[i+1:]
+}
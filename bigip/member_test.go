@@ -0,0 +1,101 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBIGIPContext_setPoolMemberState(t *testing.T) {
+	patched := map[string]map[string]interface{}{}
+	polled := 0
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PATCH":
+			body := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if patched[r.URL.EscapedPath()] == nil {
+				patched[r.URL.EscapedPath()] = map[string]interface{}{}
+			}
+			for k, v := range body {
+				patched[r.URL.EscapedPath()][k] = v
+			}
+			fmt.Fprint(w, `{}`)
+		case r.URL.Path == "/mgmt/tm/ltm/pool/~Common~pool1/members/stats":
+			polled++
+			fmt.Fprintf(w, `{"entries": {"https://localhost/mgmt/tm/ltm/pool/~Common~pool1/members/~Common~10.0.0.1:80/stats": {
+				"nestedStats": {"entries": {"serverside.curConns": {"value": %d}}}
+			}}}`, 3-polled)
+		default:
+			w.WriteHeader(404)
+		}
+	})
+	defer done()
+
+	// the member forced offline is disabled then.
+	if err := bc.ForceOfflinePoolMember("pool1", "10.0.0.1:80", "Common", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.DisablePoolMember("pool1", "10.0.0.1:80", "Common", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ForceOfflineNode("10.0.0.2", "Common"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ForceOfflineNode("10.0.0.3", "Common"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.DisableNode("10.0.0.3", "Common"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]interface{}{
+		"/mgmt/tm/ltm/pool/~Common~pool1/members/~Common~10.0.0.1%3A80": {"session": "user-disabled", "state": "user-up"},
+		"/mgmt/tm/ltm/node/~Common~10.0.0.2":                            {"session": "user-disabled", "state": "user-down"},
+		"/mgmt/tm/ltm/node/~Common~10.0.0.3":                            {"session": "user-disabled", "state": "user-up"},
+	}
+	if !reflect.DeepEqual(patched, want) {
+		t.Errorf("patched = %v, want %v", patched, want)
+	}
+
+	if err := bc.WaitPoolMemberDrained("pool1", "/Common/10.0.0.1:80", "Common", ""); err != nil {
+		t.Fatal(err)
+	}
+	if polled != 3 {
+		t.Errorf("polled %d times, want 3", polled)
+	}
+	if err := bc.WaitPoolMemberDrained("pool1", "10.0.0.9:80", "Common", ""); err == nil {
+		t.Errorf("waiting for a nonexistent member should fail")
+	}
+}
+
+func TestBIGIPContext_PoolMembers(t *testing.T) {
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mgmt/tm/ltm/pool/~Common~pool1/members":
+			fmt.Fprint(w, `{"items":[{"name":"10.0.0.1:80","partition":"Common","fullPath":"/Common/10.0.0.1:80",
+				"address":"10.0.0.1","session":"monitor-enabled","state":"up"}]}`)
+		case "/mgmt/tm/ltm/pool/~Common~empty/members":
+			// no items for the pool without members.
+			fmt.Fprint(w, `{"kind":"tm:ltm:pool:members:memberscollectionstate",
+				"selfLink":"https://localhost/mgmt/tm/ltm/pool/~Common~empty/members?ver=16.1.0"}`)
+		default:
+			w.WriteHeader(404)
+			fmt.Fprintf(w, `{"code":404,"message":"Object not found - %s"}`, r.URL.Path)
+		}
+	})
+	defer done()
+
+	members, err := bc.PoolMembers("pool1", "Common", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].Address != "10.0.0.1" || members[0].State != "up" {
+		t.Errorf("unexpected members: %+v", members)
+	}
+	members, err = bc.PoolMembers("empty", "Common", "")
+	if err != nil || len(members) != 0 {
+		t.Errorf("members of empty pool = %+v, %v", members, err)
+	}
+}
@@ -0,0 +1,121 @@
+package f5_bigip
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+)
+
+// WithTimeout sets the timeout of each iControl call, DefaultTimeout is used if not set.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *bigipOptions) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid timeout: %s", timeout)
+		}
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithTLSConfig replaces the TLS settings used to connect BIG-IP.
+// By default, the certificate of BIG-IP is not verified.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *bigipOptions) error {
+		if config == nil {
+			return fmt.Errorf("tls config is nil")
+		}
+		o.tlsConfig = config.Clone()
+		return nil
+	}
+}
+
+// WithBasicAuth authenticates each call with the Basic Authorization header.
+func WithBasicAuth(user, password string) Option {
+	return func(o *bigipOptions) error {
+		o.authorization = basicAuthorization(user, password)
+		o.token = nil
+		return nil
+	}
+}
+
+// WithTokenAuth authenticates each call with the X-F5-Auth-Token header.
+// The token is acquired from /mgmt/shared/authn/login with the given loginProviderName,
+// DefaultLoginProvider is used if it's empty.
+func WithTokenAuth(user, password, loginProviderName string) Option {
+	return func(o *bigipOptions) error {
+		if loginProviderName == "" {
+			loginProviderName = DefaultLoginProvider
+		}
+		o.authorization = ""
+		o.token = &tokenAuth{
+			username:          user,
+			password:          password,
+			loginProviderName: loginProviderName,
+		}
+		return nil
+	}
+}
+
+// WithLazyVersion skips the version discovery in NewBIGIP,
+// the version is queried at the first call of GetVersion instead.
+func WithLazyVersion() Option {
+	return func(o *bigipOptions) error {
+		o.lazyVersion = true
+		return nil
+	}
+}
+
+// WithPartitions makes NewBIGIP create the given partitions if they don't exist.
+func WithPartitions(partitions ...string) Option {
+	return func(o *bigipOptions) error {
+		o.partitions = append(o.partitions, partitions...)
+		return nil
+	}
+}
+
+// WithRetryPolicy retries the retryable failures of iControl calls with the given policy,
+// instead of DefaultRetryPolicy. The zero RetryPolicy disables retrying.
+// Calls within a transaction are not retried one by one, instead,
+// DoRestRequests restarts the whole transaction.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *bigipOptions) error {
+		if policy.MaxAttempts < 0 || policy.InitialBackoff < 0 || policy.MaxBackoff < 0 || policy.Deadline < 0 {
+			return fmt.Errorf("invalid retry policy: %+v", policy)
+		}
+		o.retry = policy
+		return nil
+	}
+}
+
+// WithAsyncTasks runs the long-running commands, like saving sys config, as asynchronous tasks,
+// which are polled until done instead of being limited by the timeout of a single iControl call.
+func WithAsyncTasks() Option {
+	return func(o *bigipOptions) error {
+		o.asyncTasks = true
+		return nil
+	}
+}
+
+// WithMinimalPatch makes GenRestRequests PATCH only the fields changed from the existing resources,
+// instead of the whole desired body, to avoid the side effects of touching the unchanged properties.
+// The arrays like profiles, rules and members are compared item by item, so the subcollections, like
+// pool members and virtual profiles, are listed along with the existing resources, which costs more.
+func WithMinimalPatch() Option {
+	return func(o *bigipOptions) error {
+		o.minimalPatch = true
+		return nil
+	}
+}
+
+// WithMaxTransCommands limits the number of commands in a transaction, 0 means no limit.
+// Larger requests are split into batches at the boundaries of ResOrder, committed one by one,
+// so that the dependencies are committed before their dependents.
+func WithMaxTransCommands(n int) Option {
+	return func(o *bigipOptions) error {
+		if n < 0 {
+			return fmt.Errorf("invalid max transaction commands: %d", n)
+		}
+		o.maxTransCommands = n
+		return nil
+	}
+}
@@ -0,0 +1,169 @@
+package f5_bigip
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// minimalPatches replaces the bodies of the PATCH requests with the fields changed from the existing resources,
+// the requests with nothing changed are dropped. It's used with WithMinimalPatch.
+func minimalPatches(uu []RestRequest, existings *map[string]map[string]interface{}) []RestRequest {
+	patches := []RestRequest{}
+	for _, r := range uu {
+		body, ok := r.Body.(map[string]interface{})
+		existing := getFromExists(r.Kind, r.Partition, r.Subfolder, r.ResName, existings)
+		if !ok || existing == nil {
+			patches = append(patches, r)
+			continue
+		}
+		props, _ := (*existing).(map[string]interface{})
+		if delta := patchDelta(body, props); delta != nil {
+			r.Body = delta
+			patches = append(patches, r)
+		}
+	}
+	return patches
+}
+
+// patchDelta returns the fields of body changed from the existing props, with the identity fields kept,
+// or nil if nothing is changed.
+func patchDelta(body, props map[string]interface{}) map[string]interface{} {
+	delta := map[string]interface{}{}
+	changed := false
+	for k, v := range body {
+		switch k {
+		case "name", "partition", "subPath":
+			delta[k] = v
+			continue
+		}
+		if fieldChanged(k, v, props[k]) {
+			delta[k] = v
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return delta
+}
+
+// fieldChanged tells if the desired value of the field differs from the existing one.
+// Numbers are compared as parsed from JSON, and names as references, see sameRef.
+// The arrays are replaced as a whole by PATCH, so they're changed unless they have the same items:
+// "rules" in the same order, the others in any order, matched by "name" if the items are objects.
+func fieldChanged(field string, desired, existing interface{}) bool {
+	if existing == nil {
+		return desired != nil
+	}
+	d, err := utils.DeepCopy(desired)
+	if err != nil {
+		return true
+	}
+	e, err := utils.DeepCopy(existing)
+	if err != nil {
+		return true
+	}
+	return !valueMatched(field, d, e)
+}
+
+func valueMatched(field string, desired, existing interface{}) bool {
+	switch dv := desired.(type) {
+	case map[string]interface{}:
+		ev, ok := existing.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range dv {
+			if !valueMatched(k, v, ev[k]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		ev, ok := existing.([]interface{})
+		if !ok || len(dv) != len(ev) {
+			return false
+		}
+		if field == "rules" {
+			for i := range dv {
+				if !valueMatched("", dv[i], ev[i]) {
+					return false
+				}
+			}
+			return true
+		}
+		used := make([]bool, len(ev))
+		for _, di := range dv {
+			found := false
+			for j, ej := range ev {
+				if !used[j] && itemMatched(di, ej) {
+					used[j], found = true, true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case string:
+		ev, ok := existing.(string)
+		return ok && sameRef(dv, ev)
+	default:
+		return reflect.DeepEqual(desired, existing)
+	}
+}
+
+// itemMatched tells if the item of an array, like a profile or pool member, is the same as the existing one.
+// A name only, like "http", matches the existing object {"name": "http", ...}.
+func itemMatched(desired, existing interface{}) bool {
+	ename := existing
+	if m, ok := existing.(map[string]interface{}); ok {
+		ename = m["name"]
+	}
+	switch dv := desired.(type) {
+	case string:
+		en, ok := ename.(string)
+		return ok && sameRef(dv, en)
+	case map[string]interface{}:
+		if dn, ok := dv["name"].(string); ok {
+			en, ok := ename.(string)
+			if !ok || !sameRef(dn, en) {
+				return false
+			}
+		}
+		return valueMatched("", dv, existing)
+	default:
+		return valueMatched("", desired, existing)
+	}
+}
+
+// sameRef tells if the strings are the same, or the desired name refers to the existing full path,
+// like "pool1" to "/p1/f1/pool1".
+func sameRef(desired, existing string) bool {
+	if desired == existing {
+		return true
+	}
+	return desired != "" && !strings.HasPrefix(desired, "/") &&
+		strings.HasPrefix(existing, "/") && strings.HasSuffix(existing, "/"+desired)
+}
+
+// inlineSubcollections sets the items of the expanded subcollections, like membersReference of a pool listed
+// with ExpandSubcollections, as the property without "Reference", like members, to be compared by minimalPatches.
+func inlineSubcollections(props map[string]interface{}) {
+	for k, v := range props {
+		ref, ok := v.(map[string]interface{})
+		if !ok || !strings.HasSuffix(k, "Reference") || ref["isSubcollection"] != true {
+			continue
+		}
+		items, ok := ref["items"].([]interface{})
+		if !ok {
+			continue
+		}
+		if name := strings.TrimSuffix(k, "Reference"); props[name] == nil {
+			props[name] = items
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func Test_patchDelta(t *testing.T) {
+	props := map[string]interface{}{}
+	json.Unmarshal([]byte(`{
+		"name": "vs1",
+		"partition": "p1",
+		"fullPath": "/p1/vs1",
+		"destination": "/p1/10.0.0.1:80",
+		"pool": "/p1/pool1",
+		"connectionLimit": 0,
+		"rules": ["/p1/r1", "/Common/r2"],
+		"profiles": [
+			{"name": "http", "partition": "Common", "fullPath": "/Common/http", "context": "all"},
+			{"name": "tcp", "partition": "Common", "fullPath": "/Common/tcp", "context": "all"}
+		],
+		"members": [
+			{"name": "10.0.0.2:80", "address": "10.0.0.2", "ratio": 1},
+			{"name": "10.0.0.1:80", "address": "10.0.0.1", "ratio": 1}
+		]
+	}`), &props)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "nothing changed",
+			body: `{"name": "vs1", "destination": "/p1/10.0.0.1:80", "pool": "pool1", "connectionLimit": 0,
+				"rules": ["r1", "/Common/r2"],
+				"profiles": [{"name": "tcp"}, "http"],
+				"members": [{"name": "10.0.0.1:80", "address": "10.0.0.1"}, {"name": "10.0.0.2:80", "address": "10.0.0.2"}]}`,
+			want: `null`,
+		},
+		{
+			name: "scalar changed",
+			body: `{"name": "vs1", "destination": "/p1/10.0.0.2:80", "pool": "pool1", "description": "web"}`,
+			want: `{"name": "vs1", "destination": "/p1/10.0.0.2:80", "description": "web"}`,
+		},
+		{
+			name: "rules reordered",
+			body: `{"name": "vs1", "rules": ["/Common/r2", "r1"], "profiles": ["http", "tcp"]}`,
+			want: `{"name": "vs1", "rules": ["/Common/r2", "r1"]}`,
+		},
+		{
+			name: "profile removed",
+			body: `{"name": "vs1", "profiles": ["tcp"]}`,
+			want: `{"name": "vs1", "profiles": ["tcp"]}`,
+		},
+		{
+			name: "member changed",
+			body: `{"name": "vs1", "members": [{"name": "10.0.0.1:80", "ratio": 2}, {"name": "10.0.0.2:80"}]}`,
+			want: `{"name": "vs1", "members": [{"name": "10.0.0.1:80", "ratio": 2}, {"name": "10.0.0.2:80"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := map[string]interface{}{}
+			json.Unmarshal([]byte(tt.body), &body)
+			var want map[string]interface{}
+			json.Unmarshal([]byte(tt.want), &want)
+			if got := patchDelta(body, props); !reflect.DeepEqual(got, want) {
+				t.Errorf("patchDelta() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_minimalPatches(t *testing.T) {
+	existings := map[string]map[string]interface{}{
+		"ltm/pool": {
+			"p1/pool1": map[string]interface{}{"name": "pool1", "loadBalancingMode": "round-robin", "monitor": "/Common/http"},
+			"p1/pool2": map[string]interface{}{"name": "pool2", "loadBalancingMode": "round-robin"},
+		},
+	}
+	uu := []RestRequest{
+		{Method: "PATCH", Kind: "ltm/pool", ResName: "pool1", Partition: "p1", WithTrans: true,
+			Body: map[string]interface{}{"name": "pool1", "loadBalancingMode": "least-connections-member", "monitor": "/Common/http"}},
+		{Method: "PATCH", Kind: "ltm/pool", ResName: "pool2", Partition: "p1", WithTrans: true,
+			Body: map[string]interface{}{"name": "pool2", "loadBalancingMode": "round-robin"}},
+	}
+	patches := minimalPatches(uu, &existings)
+	if len(patches) != 1 {
+		t.Fatalf("unexpected patches: %+v", patches)
+	}
+	want := map[string]interface{}{"name": "pool1", "loadBalancingMode": "least-connections-member"}
+	if !reflect.DeepEqual(patches[0].Body, want) {
+		t.Errorf("body = %v, want %v", patches[0].Body, want)
+	}
+}
+
+func TestBIGIPContext_GenPlan_minimalPatch(t *testing.T) {
+	handler := func(expand bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/mgmt/tm/ltm/pool" {
+				fmt.Fprint(w, `{"items":[]}`)
+				return
+			}
+			if got := r.URL.Query().Get("expandSubcollections") == "true"; got != expand {
+				t.Errorf("listing pools with subcollections = %v, want %v", got, expand)
+			}
+			fmt.Fprint(w, `{"items":[{"kind":"tm:ltm:pool:poolstate","name":"pool1","partition":"p1","fullPath":"/p1/pool1",
+				"loadBalancingMode":"round-robin",
+				"membersReference":{"link":"https://localhost/mgmt/tm/ltm/pool/~p1~pool1/members?ver=16.1.0","isSubcollection":true,
+					"items":[{"kind":"tm:ltm:pool:members:membersstate","name":"10.0.0.1:80","partition":"p1","fullPath":"/p1/10.0.0.1:80",
+						"address":"10.0.0.1","ratio":1,"state":"up","session":"monitor-enabled"}]}}]}`)
+		}
+	}
+	cfg := func(members string) map[string]interface{} {
+		c := map[string]interface{}{}
+		json.Unmarshal([]byte(`{"": {"ltm/pool/pool1": {"name": "pool1", "loadBalancingMode": "round-robin",
+			"members": `+members+`}}}`), &c)
+		return c
+	}
+
+	bc, done := newTestBIGIPContext(t, handler(true), WithMinimalPatch())
+	defer done()
+	same := cfg(`[{"name": "10.0.0.1:80", "address": "10.0.0.1"}]`)
+	plan, _, err := bc.GenPlan("p1", &same, &same)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() {
+		t.Errorf("unexpected plan of the same members: %s", plan)
+	}
+	added := cfg(`[{"name": "10.0.0.1:80", "address": "10.0.0.1"}, {"name": "10.0.0.2:80", "address": "10.0.0.2"}]`)
+	plan, cmds, err := bc.GenPlan("p1", &same, &added)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Update) != 1 || len((*cmds)[0].Body.(map[string]interface{})["members"].([]interface{})) != 2 {
+		t.Errorf("unexpected plan of the added member: %s", plan)
+	}
+	if _, f := (*cmds)[0].Body.(map[string]interface{})["loadBalancingMode"]; f {
+		t.Errorf("unchanged field is patched: %v", (*cmds)[0].Body)
+	}
+
+	// the subcollections are not listed without minimal patch.
+	bc, done = newTestBIGIPContext(t, handler(false))
+	defer done()
+	if _, _, err := bc.GenPlan("p1", &same, &same); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,187 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// GenPlan generates the rest requests of the partition like GenRestRequests, with the existing resources
+// got from BIG-IP, and returns the Plan of them as well, so that the changes can be reviewed before DoRestRequests.
+func (bc *BIGIPContext) GenPlan(partition string, ocfg, ncfg *map[string]interface{}) (*Plan, *[]RestRequest, error) {
+	kinds := GatherKinds(ocfg, ncfg)
+	existings, err := bc.GetExistingResources(partition, kinds)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmds, err := bc.GenRestRequests(partition, ocfg, ncfg, existings)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewPlan(*cmds, existings), cmds, nil
+}
+
+// NewPlan builds the Plan of the rest requests, the fields to update are compared with existings,
+// which is the same as the one passed to GenRestRequests.
+func NewPlan(rr []RestRequest, existings *map[string]map[string]interface{}) *Plan {
+	plan := Plan{
+		Create: []PlanChange{},
+		Update: []PlanChange{},
+		Delete: []PlanChange{},
+		Other:  []PlanChange{},
+	}
+	for _, r := range rr {
+		if r.Method == "NOPE" {
+			continue
+		}
+		c := PlanChange{
+			Partition: r.Partition,
+			Folder:    r.Subfolder,
+			Kind:      r.Kind,
+			Name:      r.ResName,
+			Method:    r.Method,
+			Schedule:  r.ScheduleIt,
+		}
+		switch {
+		case !r.WithTrans || r.ScheduleIt != "":
+			plan.Other = append(plan.Other, c)
+		case r.Method == "POST":
+			c.Body = r.Body
+			plan.Create = append(plan.Create, c)
+		case r.Method == "PATCH":
+			c.Diff = fieldDiffs(r.Body, getFromExists(r.Kind, r.Partition, r.Subfolder, r.ResName, existings))
+			plan.Update = append(plan.Update, c)
+		case r.Method == "DELETE":
+			plan.Delete = append(plan.Delete, c)
+		default:
+			plan.Other = append(plan.Other, c)
+		}
+	}
+	for _, changes := range [][]PlanChange{plan.Create, plan.Update, plan.Delete} {
+		sort.SliceStable(changes, func(i, j int) bool {
+			return planChangeKey(changes[i]) < planChangeKey(changes[j])
+		})
+	}
+	return &plan
+}
+
+// Empty tells if nothing would be changed by the plan.
+func (p *Plan) Empty() bool {
+	return len(p.Create)+len(p.Update)+len(p.Delete)+len(p.Other) == 0
+}
+
+// JSON renders the plan as JSON.
+func (p *Plan) JSON() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// String renders the plan as text, by partition and folder:
+//
+//	Plan: 1 to create, 1 to update, 1 to delete, 0 other.
+//
+//	/p1/f1:
+//	  + ltm/pool pool1
+//	      + loadBalancingMode = "round-robin"
+//	  ~ ltm/virtual vs1
+//	      ~ destination = "/p1/1.1.1.1:80" -> "/p1/1.1.1.2:80"
+//	  - ltm/monitor/http mon1
+func (p *Plan) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Plan: %d to create, %d to update, %d to delete, %d other.\n",
+		len(p.Create), len(p.Update), len(p.Delete), len(p.Other))
+
+	folders := []string{}
+	byFolder := map[string][]string{}
+	add := func(c PlanChange, lines ...string) {
+		folder := "/" + c.Partition
+		if c.Folder != "" {
+			folder += "/" + c.Folder
+		}
+		if _, f := byFolder[folder]; !f {
+			folders = append(folders, folder)
+		}
+		byFolder[folder] = append(byFolder[folder], lines...)
+	}
+
+	for _, c := range p.Create {
+		lines := []string{fmt.Sprintf("  + %s %s", c.Kind, c.Name)}
+		if body, ok := c.Body.(map[string]interface{}); ok {
+			for _, k := range sortedKeys(body) {
+				lines = append(lines, fmt.Sprintf("      + %s = %s", k, planValue(body[k])))
+			}
+		}
+		add(c, lines...)
+	}
+	for _, c := range p.Update {
+		lines := []string{fmt.Sprintf("  ~ %s %s", c.Kind, c.Name)}
+		for _, d := range c.Diff {
+			if d.Old == nil {
+				lines = append(lines, fmt.Sprintf("      + %s = %s", d.Field, planValue(d.New)))
+			} else {
+				lines = append(lines, fmt.Sprintf("      ~ %s = %s -> %s", d.Field, planValue(d.Old), planValue(d.New)))
+			}
+		}
+		add(c, lines...)
+	}
+	for _, c := range p.Delete {
+		add(c, fmt.Sprintf("  - %s %s", c.Kind, c.Name))
+	}
+	for _, c := range p.Other {
+		line := fmt.Sprintf("  > %s %s (%s", c.Kind, c.Name, c.Method)
+		if c.Schedule != "" {
+			line += ", scheduled " + c.Schedule
+		}
+		add(c, line+")")
+	}
+
+	sort.Strings(folders)
+	for _, folder := range folders {
+		fmt.Fprintf(&sb, "\n%s:\n%s\n", folder, strings.Join(byFolder[folder], "\n"))
+	}
+	return sb.String()
+}
+
+// fieldDiffs returns the fields of body different from the existing resource,
+// in the same way of deciding whether to PATCH, see utils.FieldsIsExpected.
+func fieldDiffs(body interface{}, existing *interface{}) []FieldDiff {
+	diffs := []FieldDiff{}
+	b, ok := body.(map[string]interface{})
+	if !ok {
+		return diffs
+	}
+	e := map[string]interface{}{}
+	if existing != nil {
+		e, _ = (*existing).(map[string]interface{})
+	}
+	for _, k := range sortedKeys(b) {
+		if old, f := e[k]; !f || !reflect.DeepEqual(b[k], old) {
+			diffs = append(diffs, FieldDiff{Field: k, Old: old, New: b[k]})
+		}
+	}
+	return diffs
+}
+
+func planChangeKey(c PlanChange) string {
+	return strings.Join([]string{c.Partition, c.Folder, c.Kind, c.Name}, "\x00")
+}
+
+func planValue(v interface{}) string {
+	b, err := utils.MarshalNoEscaping(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,77 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewPlan(t *testing.T) {
+	existings := map[string]map[string]interface{}{
+		"ltm/virtual": {
+			"p1/f1/vs1": map[string]interface{}{
+				"name":        "vs1",
+				"destination": "/p1/1.1.1.1:80",
+				"pool":        "/p1/f1/pool1",
+			},
+		},
+	}
+	rr := []RestRequest{
+		{Method: "NOPE", Kind: "sys/folder", ResName: "f1", Partition: "p1", WithTrans: true},
+		{Method: "POST", Kind: "ltm/pool", ResName: "pool1", Partition: "p1", Subfolder: "f1", WithTrans: true,
+			Body: map[string]interface{}{"name": "pool1", "loadBalancingMode": "round-robin"}},
+		{Method: "PATCH", Kind: "ltm/virtual", ResName: "vs1", Partition: "p1", Subfolder: "f1", WithTrans: true,
+			Body: map[string]interface{}{"name": "vs1", "destination": "/p1/1.1.1.2:80", "pool": "/p1/f1/pool1", "description": "web"}},
+		{Method: "DELETE", Kind: "ltm/monitor/http", ResName: "mon1", Partition: "p1", WithTrans: true},
+		{Method: "POST", Kind: "shared/file-transfer/uploads", ResName: "cert1", Partition: "p1", Subfolder: "f1", Body: "data"},
+		{Method: "POST", Kind: "shared/file-transfer/uploads", ResName: "cert1", Partition: "p1", Subfolder: "f1", ScheduleIt: "after-commit"},
+	}
+
+	plan := NewPlan(rr, &existings)
+	if len(plan.Create) != 1 || len(plan.Update) != 1 || len(plan.Delete) != 1 || len(plan.Other) != 2 || plan.Empty() {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	wantDiff := []FieldDiff{
+		{Field: "description", Old: nil, New: "web"},
+		{Field: "destination", Old: "/p1/1.1.1.1:80", New: "/p1/1.1.1.2:80"},
+	}
+	if !reflect.DeepEqual(plan.Update[0].Diff, wantDiff) {
+		t.Errorf("diff = %+v, want %+v", plan.Update[0].Diff, wantDiff)
+	}
+
+	want := `Plan: 1 to create, 1 to update, 1 to delete, 2 other.
+
+/p1:
+  - ltm/monitor/http mon1
+
+/p1/f1:
+  + ltm/pool pool1
+      + loadBalancingMode = "round-robin"
+      + name = "pool1"
+  ~ ltm/virtual vs1
+      + description = "web"
+      ~ destination = "/p1/1.1.1.1:80" -> "/p1/1.1.1.2:80"
+  > shared/file-transfer/uploads cert1 (POST)
+  > shared/file-transfer/uploads cert1 (POST, scheduled after-commit)
+`
+	if got := plan.String(); got != want {
+		t.Errorf("plan text:\n%s\nwant:\n%s", got, want)
+	}
+
+	b, err := plan.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := Plan{}
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Update) != 1 || parsed.Update[0].Folder != "f1" || !strings.Contains(string(b), `"field": "destination"`) {
+		t.Errorf("unexpected plan json: %s", b)
+	}
+
+	if empty := NewPlan([]RestRequest{}, nil); !empty.Empty() || !strings.HasPrefix(empty.String(), "Plan: 0 to create") {
+		t.Errorf("unexpected empty plan: %s", empty)
+	}
+}
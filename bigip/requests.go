@@ -4,27 +4,89 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/f5devcentral/f5-bigip-rest-go/utils"
 )
 
+// DoRestRequests executes the rest requests in one transaction.
+// If the transaction fails with a retryable error, it's restarted from MakeTrans following the retry policy,
+// because the commands cannot be replayed into a dead transaction.
+// A failed commit is returned as *TransactionError naming the offending request if it can be told.
+// The requests with ScheduleIt are handed to the syncer after the commit, see ScheduledResults.
+// If the commands exceed the limit of WithMaxTransCommands, they are committed in batches, see DoRestRequestsInBatches.
 func (bc *BIGIPContext) DoRestRequests(rr *[]RestRequest) error {
 	if rr == nil || len(*rr) == 0 {
 		slog := utils.LogFromContext(bc.Context)
 		slog.Debugf("empty rest requests, skip deploying")
 		return nil
 	}
-	if transId, err := bc.MakeTrans(); err != nil {
+	if bc.maxTransCommands > 0 && transCommandCount(*rr) > bc.maxTransCommands {
+		_, err := bc.DoRestRequestsInBatches(rr)
 		return err
-	} else {
-		if count, err := bc.DeployWithTrans(rr, transId); err != nil || count == 0 {
-			return err
-		} else {
-			return bc.CommitTrans(transId)
+	}
+	err := bc.withRetry("transaction", func() error {
+		return bc.doRestRequestsInTrans(rr)
+	})
+	if err == nil {
+		bc.schedule(*rr)
+	}
+	return err
+}
+
+func (bc *BIGIPContext) doRestRequestsInTrans(rr *[]RestRequest) error {
+	transId, err := bc.MakeTrans()
+	if err != nil {
+		return err
+	}
+	cmds, err := bc.deployWithTrans(rr, transId, false)
+	if err == nil && len(cmds) > 0 {
+		if err = bc.CommitTrans(transId); err != nil && bc.ctx().Err() == nil {
+			err = bc.transactionError(transId, cmds, err)
 		}
 	}
+	if err != nil {
+		bc.cleanupTrans(transId)
+	}
+	return err
+}
+
+// ValidateRestRequests is the dry run of DoRestRequests: the requests are sent into a transaction,
+// which is validated by BIG-IP without committing, and deleted then. nil is returned if they would succeed.
+// The requests not in transaction, like uploading files, are skipped because they take effect immediately.
+func (bc *BIGIPContext) ValidateRestRequests(rr *[]RestRequest) error {
+	slog := utils.LogFromContext(bc.Context)
+	if rr == nil || len(*rr) == 0 {
+		slog.Debugf("empty rest requests, skip validating")
+		return nil
+	}
+
+	transId, err := bc.MakeTrans()
+	if err != nil {
+		return err
+	}
+	defer bc.cleanupTrans(transId)
+
+	cmds, err := bc.deployWithTrans(rr, transId, true)
+	if err != nil || len(cmds) == 0 {
+		return err
+	}
+	if err := bc.ValidateTrans(transId); err != nil {
+		return bc.transactionError(transId, cmds, err)
+	}
+	return nil
+}
+
+// cleanupTrans deletes the transaction failed, abandoned by cancellation or validated only, best-effort.
+func (bc *BIGIPContext) cleanupTrans(transId float64) {
+	slog := utils.LogFromContext(bc.Context)
+	dbc, cancel := bc.detached(transCleanupTimeout)
+	defer cancel()
+	if err := dbc.DeleteTrans(transId); err != nil {
+		slog.Warnf("failed to clean up transaction %.f: %s", transId, err.Error())
+	} else {
+		slog.Debugf("cleaned up transaction %.f", transId)
+	}
 }
 
 func (bc *BIGIPContext) constructFolder(name, partition string) RestRequest {
@@ -107,15 +169,13 @@ func (bc *BIGIPContext) constructSharedRes(kind, name, partition, subfolder stri
 	case "shared/file-transfer/uploads":
 		if operation == "deploy" {
 			rawbody := body.(map[string]interface{})["content"].(string)
-			size := len(rawbody)
 			r = RestRequest{
 				Method: "POST",
 				Body:   rawbody,
 				ResUri: "/mgmt/shared/file-transfer/uploads/" + name,
+				// Content-Range is set per chunk when uploading.
 				Headers: map[string]interface{}{
-					"Content-Type":   "application/octet-stream",
-					"Content-Length": fmt.Sprintf("%d", size),
-					"Content-Range":  fmt.Sprintf("0-%d/%d", size-1, size),
+					"Content-Type": "application/octet-stream",
 				},
 				Partition: partition,
 				Subfolder: subfolder,
@@ -124,22 +184,9 @@ func (bc *BIGIPContext) constructSharedRes(kind, name, partition, subfolder stri
 				WithTrans: false,
 			}
 		} else if operation == "delete" {
-			// the uploaded file would be removed automatically by BIG-IP,
+			// the uploaded file is removed after the deploying transaction is committed,
 			// we needn't to handle it.
-			r = RestRequest{
-				ScheduleIt: "never",
-				Method:     "POST",
-				Body: map[string]interface{}{
-					"command":     "run",
-					"utilCmdArgs": fmt.Sprintf("-c 'rm -f /var/config/rest/downloads/%s'", name),
-				},
-				ResUri:    "/mgmt/tm/util/bash",
-				Partition: partition,
-				Subfolder: subfolder,
-				ResName:   name,
-				Kind:      kind,
-				WithTrans: false,
-			}
+			r = bc.constructUploadCleanup(name, partition, subfolder, "never")
 		}
 
 	default:
@@ -149,43 +196,76 @@ func (bc *BIGIPContext) constructSharedRes(kind, name, partition, subfolder stri
 	return r, nil
 }
 
+// constructUploadCleanup removes the uploaded file, which is useless once imported by the transaction.
+func (bc *BIGIPContext) constructUploadCleanup(name, partition, subfolder, schedule string) RestRequest {
+	kind := "shared/file-transfer/uploads"
+	return RestRequest{
+		ScheduleIt: schedule,
+		Method:     "POST",
+		Body: map[string]interface{}{
+			"command":     "run",
+			"utilCmdArgs": NewBashCommand("rm", "-f", "/var/config/rest/downloads/"+name).utilCmdArgs(),
+		},
+		ResUri:    "/mgmt/tm/util/bash",
+		Partition: partition,
+		Subfolder: subfolder,
+		ResName:   name,
+		Kind:      kind,
+		WithTrans: false,
+	}
+}
+
+// GetExistingResources lists the resources of the kinds in the partition, by the kind and then the keyname.
+// With WithMinimalPatch, the subcollections like pool members and virtual profiles are listed as well,
+// for minimalPatches to compare them, see inlineSubcollections.
 func (bc *BIGIPContext) GetExistingResources(partition string, kinds []string) (*map[string]map[string]interface{}, error) {
+	return bc.existingResources(partition, kinds, bc.minimalPatch)
+}
+
+// existingResources does the same as GetExistingResources, with the subcollections listed if expand.
+func (bc *BIGIPContext) existingResources(partition string, kinds []string, expand bool) (*map[string]map[string]interface{}, error) {
 	defer utils.TimeItToPrometheus()()
 	slog := utils.LogFromContext(bc.Context)
 
 	exists := map[string]map[string]interface{}{}
 
-	regxNotfound := regexp.MustCompile(`The requested \w+ (.*) was not found.`)
-	regxNoFolder := regexp.MustCompile(`The requested folder (.*) was not found.`)
-
 	for _, kind := range kinds {
 		if !KindIsSupported(kind) {
 			slog.Errorf("kind %s not support, yet", kind)
 			continue
 		}
 		exists[kind] = map[string]interface{}{}
-		resp, err := bc.All(fmt.Sprintf("%s?$filter=partition+eq+%s", kind, partition))
+		if strings.HasPrefix(kind, "shared/") {
+			// shared/ kinds, like file-transfer/uploads, are not listable under /mgmt/tm.
+			continue
+		}
+		query := NewListQuery().Filter("partition", partition).Top(DefaultListPageSize)
+		if expand {
+			query.ExpandSubcollections()
+		}
+		err := bc.List(kind, query, func(props map[string]interface{}) error {
+			n, ok := props["name"].(string)
+			if !ok {
+				slog.Warnf("skipping %s without name: %v", kind, props["selfLink"])
+				return nil
+			}
+			p, f := partition, ""
+			if ff, ok := props["subPath"]; ok {
+				f = ff.(string)
+			}
+			if expand {
+				inlineSubcollections(props)
+			}
+			exists[kind][utils.Keyname(p, f, n)] = props
+			return nil
+		})
 		if err != nil {
-			if regxNoFolder.MatchString(err.Error()) {
+			if isFolderNotFound(err) {
 				return &exists, nil
-			} else if regxNotfound.MatchString(err.Error()) {
+			} else if IsNotFound(err) {
 				continue
 			} else {
-				return nil, fmt.Errorf("failed to list '%s' of %s: %s", kind, partition, err.Error())
-			}
-		}
-
-		if items, ok := (*resp)["items"]; !ok {
-			// return nil, fmt.Errorf("failed to get items from response")
-			slog.Warnf("failed to get items from response for %s", kind)
-		} else {
-			for _, item := range items.([]interface{}) {
-				props := item.(map[string]interface{})
-				p, f, n := partition, "", props["name"].(string)
-				if ff, ok := props["subPath"]; ok {
-					f = ff.(string)
-				}
-				exists[kind][utils.Keyname(p, f, n)] = props
+				return nil, fmt.Errorf("failed to list '%s' of %s: %w", kind, partition, err)
 			}
 		}
 	}
@@ -228,18 +308,23 @@ func (bc *BIGIPContext) GenRestRequests(partition string, ocfg, ncfg *map[string
 
 	rDels := map[string][]RestRequest{}
 	rCrts := map[string][]RestRequest{}
+	scheduled := []RestRequest{}
 
 	if ocfg != nil {
 		var err error
-		if rDels, err = bc.cfg2RestRequests(partition, "delete", *ocfg, existings); err != nil {
+		var rs []RestRequest
+		if rDels, rs, err = bc.cfg2RestRequests(partition, "delete", *ocfg, existings); err != nil {
 			return &[]RestRequest{}, err
 		}
+		scheduled = append(scheduled, rs...)
 	}
 	if ncfg != nil {
 		var err error
-		if rCrts, err = bc.cfg2RestRequests(partition, "deploy", *ncfg, existings); err != nil {
+		var rs []RestRequest
+		if rCrts, rs, err = bc.cfg2RestRequests(partition, "deploy", *ncfg, existings); err != nil {
 			return &[]RestRequest{}, err
 		}
+		scheduled = append(scheduled, rs...)
 	}
 
 	vcmdDels, vcmdCrts := []RestRequest{}, []RestRequest{}
@@ -279,9 +364,14 @@ func (bc *BIGIPContext) GenRestRequests(partition string, ocfg, ncfg *map[string
 	}
 
 	cl, dl, ul := sweepCmds(rDels, rCrts, existings)
+	if bc.minimalPatch {
+		ul = minimalPatches(ul, existings)
+	}
 	cmds := layoutCmds(cl, dl, ul)
 	cmds = append(cmds, vcmdDels...)
 	cmds = append(cmds, vcmdCrts...)
+	// the scheduled ones go last, handed to the syncer once the others are committed.
+	cmds = append(cmds, scheduled...)
 
 	// if there is virtual-address change...
 
@@ -292,10 +382,12 @@ func (bc *BIGIPContext) GenRestRequests(partition string, ocfg, ncfg *map[string
 	return &cmds, nil
 }
 
-func (bc *BIGIPContext) cfg2RestRequests(partition, operation string, cfg map[string]interface{}, exists *map[string]map[string]interface{}) (map[string][]RestRequest, error) {
+// cfg2RestRequests returns the requests by kinds, and the ones with ScheduleIt separately.
+func (bc *BIGIPContext) cfg2RestRequests(partition, operation string, cfg map[string]interface{}, exists *map[string]map[string]interface{}) (map[string][]RestRequest, []RestRequest, error) {
 	slog := utils.LogFromContext(bc.Context)
 	slog.Tracef("generating '%s' cmds for partition %s's config", operation, partition)
 	rrs := map[string][]RestRequest{}
+	scheduled := []RestRequest{}
 
 	for fn, ress := range cfg {
 		if fn != "" {
@@ -329,24 +421,27 @@ func (bc *BIGIPContext) cfg2RestRequests(partition, operation string, cfg map[st
 				r.Method = opr2method(operation, nil != getFromExists(t, partition, fn, n, exists))
 			case "shared":
 				r, err = bc.constructSharedRes(t, n, partition, fn, body, operation)
+				if err == nil && t == "shared/file-transfer/uploads" && operation == "deploy" {
+					scheduled = append(scheduled, bc.constructUploadCleanup(n, partition, fn, "after-commit"))
+				}
 			default:
-				return rrs, fmt.Errorf("not support root kind: %s", rootKind)
+				return rrs, scheduled, fmt.Errorf("not support root kind: %s", rootKind)
 			}
 			if err != nil {
-				return rrs, err
+				return rrs, scheduled, err
 			} else {
 				if _, f := rrs[t]; !f {
 					rrs[t] = []RestRequest{}
 				}
 				if r.ScheduleIt != "" {
-					// TODO: add it to resSyncer
+					scheduled = append(scheduled, r)
 				} else {
 					rrs[t] = append(rrs[t], r)
 				}
 			}
 		}
 	}
-	return rrs, nil
+	return rrs, scheduled, nil
 }
 
 // DeployPartition create the specified partition if not exists on BIG-IP
@@ -460,7 +555,7 @@ func (bc *BIGIPContext) ListPartitions() ([]string, error) {
 	slog := utils.LogFromContext(bc)
 	resp, err := bc.All("sys/folder")
 	if err != nil {
-		return partitions, fmt.Errorf("failed to list partitions: %s", err.Error())
+		return partitions, fmt.Errorf("failed to list partitions: %w", err)
 	}
 
 	if items, ok := (*resp)["items"]; !ok {
@@ -480,25 +575,41 @@ func (bc *BIGIPContext) ListPartitions() ([]string, error) {
 	return utils.Unified(partitions), nil
 }
 
+// SaveSysConfig saves the config of the partitions, or all if partitions is empty.
+// It runs as an asynchronous task if BIGIP is created WithAsyncTasks.
 func (bc *BIGIPContext) SaveSysConfig(partitions []string) error {
 	slog := utils.LogFromContext(bc.Context)
 
-	cmd := "save sys config"
-	if len(partitions) > 0 {
-		cmd += "partitions { "
-
-		for _, p := range partitions {
-			cmd += p + " "
-		}
-		cmd += "}"
+	if bc.asyncTasks {
+		return bc.saveSysConfigTask(partitions)
 	}
 
-	resp, err := bc.Tmsh(cmd)
+	cmd := NewTmshCommand("save", "sys", "config")
+	if len(partitions) > 0 {
+		cmd.Arg("partitions").Block(partitions...)
+	}
+	result, err := bc.Run(cmd)
 	if err != nil {
 		return err
 	}
-	if (*resp)["commandResult"] != nil {
-		slog.Warnf("command %s: %v", cmd, (*resp)["commandResult"])
+	if result.Stdout != "" {
+		slog.Warnf("command %s: %s", cmd, result.Stdout)
+	}
+	return nil
+}
+
+// saveSysConfigTask saves sys config with the task of sys/config, used with WithAsyncTasks.
+func (bc *BIGIPContext) saveSysConfigTask(partitions []string) error {
+	body := map[string]interface{}{
+		"command": "save",
+	}
+	if len(partitions) > 0 {
+		body["options"] = []map[string]string{
+			{"partitions": "{ " + strings.Join(partitions, " ") + " }"},
+		}
+	}
+	if _, err := bc.RunTask("sys/config", body); err != nil {
+		return fmt.Errorf("failed to save sys config: %w", err)
 	}
 	return nil
 }
@@ -506,20 +617,15 @@ func (bc *BIGIPContext) SaveSysConfig(partitions []string) error {
 func (bc *BIGIPContext) ModifyDbValue(name, value string) error {
 	slog := utils.LogFromContext(bc.Context)
 	// modify sys db tmrouted.tmos.routing value enable
-	cmd := "modify sys db "
-	cmd += name
-	cmd += " value "
-	cmd += value
+	cmd := NewTmshCommand("modify", "sys", "db", name, "value", value)
 	slog.Debugf("cmd is: %s", cmd)
 
-	resp, err := bc.Tmsh(cmd)
-
+	result, err := bc.Run(cmd)
 	if err != nil {
 		return err
 	}
-
-	if (*resp)["commandResult"] != nil {
-		slog.Warnf("command %s: %v", cmd, (*resp)["commandResult"])
+	if result.Stdout != "" {
+		slog.Warnf("command %s: %s", cmd, result.Stdout)
 	}
 	return nil
 }
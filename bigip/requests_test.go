@@ -0,0 +1,269 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBIGIPContext_ValidateRestRequests(t *testing.T) {
+	requests := []string{}
+	var mutex sync.Mutex
+	valid := true
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, r.Header.Get("X-F5-REST-Coordination-Id")))
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/mgmt/tm/transaction":
+			fmt.Fprint(w, `{"transId":1234}`)
+		case r.Method == "PATCH" && r.URL.Path == "/mgmt/tm/transaction/1234":
+			if body["validateOnly"] != true {
+				t.Errorf("transaction is not validated only: %v", body)
+			}
+			if valid {
+				fmt.Fprint(w, `{"transId":1234,"state":"VALIDATION_SUCCEEDED"}`)
+			} else {
+				w.WriteHeader(400)
+				fmt.Fprint(w, `{"code":400,"message":"transaction failed:01020036:3: The requested pool (/p1/pool0) was not found."}`)
+			}
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	})
+	defer done()
+
+	rr := []RestRequest{
+		{Method: "POST", ResUri: "/mgmt/tm/util/bash", Kind: "util/bash", Body: map[string]interface{}{"command": "run"}},
+		{Method: "POST", ResUri: "/mgmt/tm/ltm/virtual", Kind: "ltm/virtual", ResName: "vs1", Partition: "p1",
+			Body: map[string]interface{}{"name": "vs1", "pool": "pool0"}, WithTrans: true},
+	}
+	if err := bc.ValidateRestRequests(&rr); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"POST /mgmt/tm/transaction ",
+		"POST /mgmt/tm/ltm/virtual 1234",
+		"PATCH /mgmt/tm/transaction/1234 ",
+		"DELETE /mgmt/tm/transaction/1234 ",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+
+	valid = false
+	if err := bc.ValidateRestRequests(&rr); err == nil || !strings.Contains(err.Error(), "was not found") {
+		t.Errorf("expected validation failure, got %v", err)
+	}
+}
+
+func TestBIGIPContext_DoRestRequests_transactionError(t *testing.T) {
+	deleted := false
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/mgmt/tm/transaction":
+			fmt.Fprint(w, `{"transId":1234}`)
+		case r.Method == "PATCH" && r.URL.Path == "/mgmt/tm/transaction/1234":
+			w.WriteHeader(400)
+			fmt.Fprint(w, `{"code":400,"message":"transaction failed:01070734:3: Configuration error: Virtual Server /p1/f1/vs1 destination address conflicts"}`)
+		case r.Method == "GET" && r.URL.Path == "/mgmt/tm/transaction/1234/commands":
+			fmt.Fprint(w, `{"items":[
+				{"method":"PATCH","uri":"https://localhost/mgmt/tm/ltm/virtual/~p1~f1~vs1","evalOrder":2},
+				{"method":"POST","uri":"https://localhost/mgmt/tm/ltm/pool","evalOrder":1}
+			]}`)
+		case r.Method == "DELETE" && r.URL.Path == "/mgmt/tm/transaction/1234":
+			deleted = true
+			fmt.Fprint(w, `{}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	})
+	defer done()
+
+	rr := []RestRequest{
+		{Method: "POST", ResUri: "/mgmt/tm/ltm/pool", Kind: "ltm/pool", ResName: "vs1", Partition: "p1",
+			Body: map[string]interface{}{"name": "vs1"}, WithTrans: true},
+		{Method: "PATCH", ResUri: "/mgmt/tm/ltm/virtual", Kind: "ltm/virtual", ResName: "vs1", Partition: "p1", Subfolder: "f1",
+			Body: map[string]interface{}{"name": "vs1"}, WithTrans: true},
+	}
+	err := bc.DoRestRequests(&rr)
+	var terr *TransactionError
+	if !errors.As(err, &terr) {
+		t.Fatalf("expected TransactionError, got %v", err)
+	}
+	if terr.EvalOrder != 2 || terr.Request == nil || terr.Request.Kind != "ltm/virtual" {
+		t.Errorf("unexpected failed command: %d %+v", terr.EvalOrder, terr.Request)
+	}
+	if !strings.Contains(err.Error(), "PATCH ltm/virtual /p1/f1/vs1: transaction failed") {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+	if !deleted {
+		t.Errorf("failed transaction was not deleted")
+	}
+}
+
+func TestBIGIPContext_transactionError(t *testing.T) {
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items":[
+			{"method":"POST","uri":"https://localhost/mgmt/tm/ltm/pool","evalOrder":1},
+			{"method":"POST","uri":"https://localhost/mgmt/tm/ltm/pool","evalOrder":2}
+		]}`)
+	})
+	defer done()
+
+	cmds := []RestRequest{
+		{Method: "POST", ResUri: "/mgmt/tm/ltm/pool", Kind: "ltm/pool", ResName: "pool", Partition: "p"},
+		{Method: "POST", ResUri: "/mgmt/tm/ltm/pool", Kind: "ltm/pool", ResName: "pool1", Partition: "p"},
+	}
+	tests := []struct {
+		message string
+		order   int
+	}{
+		{message: "01020066:3: The requested Pool (/p/pool1) already exists in partition p.", order: 2},
+		{message: "01020066:3: The requested Pool (/p/pool) already exists in partition p.", order: 1},
+		{message: "01070734:3: Configuration error: /p/pool10 not found", order: 0},
+	}
+	for _, tt := range tests {
+		var terr *TransactionError
+		err := bc.transactionError(1234, cmds, &BigipError{StatusCode: 400, Message: tt.message})
+		if !errors.As(err, &terr) || terr.EvalOrder != tt.order {
+			t.Errorf("failed command of '%s' = %+v, want %d", tt.message, terr, tt.order)
+		}
+	}
+}
+
+func Test_splitBatches(t *testing.T) {
+	rr := []RestRequest{
+		{Method: "POST", Kind: "shared/file-transfer/uploads", ResName: "f1"},
+		{Method: "POST", Kind: "sys/folder", ResName: "f", WithTrans: true},
+		{Method: "POST", Kind: "ltm/monitor/http", ResName: "m1", WithTrans: true},
+		{Method: "POST", Kind: "ltm/pool", ResName: "p1", WithTrans: true},
+		{Method: "POST", Kind: "ltm/pool", ResName: "p2", WithTrans: true},
+		{Method: "POST", Kind: "ltm/pool", ResName: "p3", WithTrans: true},
+		{Method: "NOPE", Kind: "ltm/pool", ResName: "p4", WithTrans: true},
+		{Method: "POST", Kind: "ltm/virtual", ResName: "vs1", WithTrans: true},
+		{Method: "DELETE", Kind: "ltm/pool", ResName: "p0", WithTrans: true},
+	}
+	names := func(batches [][]RestRequest) [][]string {
+		rlt := [][]string{}
+		for _, b := range batches {
+			ns := []string{}
+			for _, r := range b {
+				ns = append(ns, r.ResName)
+			}
+			rlt = append(rlt, ns)
+		}
+		return rlt
+	}
+
+	tests := []struct {
+		max  int
+		want [][]string
+	}{
+		{max: 0, want: [][]string{{"f1", "f", "m1", "p1", "p2", "p3", "p4", "vs1", "p0"}}},
+		{max: 7, want: [][]string{{"f1", "f", "m1", "p1", "p2", "p3", "p4", "vs1", "p0"}}},
+		{max: 5, want: [][]string{{"f1", "f", "m1", "p1", "p2", "p3", "p4"}, {"vs1", "p0"}}},
+		{max: 3, want: [][]string{{"f1", "f", "m1"}, {"p1", "p2", "p3", "p4"}, {"vs1", "p0"}}},
+		{max: 2, want: [][]string{{"f1", "f", "m1"}, {"p1", "p2"}, {"p3", "p4", "vs1"}, {"p0"}}},
+	}
+	for _, tt := range tests {
+		if got := names(splitBatches(rr, tt.max)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitBatches(%d) = %v, want %v", tt.max, got, tt.want)
+		}
+	}
+}
+
+func Test_splitBatches_inKind(t *testing.T) {
+	names := func(batches [][]RestRequest) []string {
+		rlt := []string{}
+		for _, b := range batches {
+			for _, r := range b {
+				rlt = append(rlt, r.ResName)
+			}
+		}
+		return rlt
+	}
+	profiles := []RestRequest{
+		{Method: "POST", Kind: "ltm/profile/http", ResName: "child", Partition: "p", WithTrans: true,
+			Body: map[string]interface{}{"name": "child", "defaultsFrom": "/p/parent"}},
+		{Method: "POST", Kind: "ltm/profile/http", ResName: "parent", Partition: "p", WithTrans: true,
+			Body: map[string]interface{}{"name": "parent", "defaultsFrom": "/Common/http"}},
+		{Method: "POST", Kind: "ltm/profile/http", ResName: "other", Partition: "p", WithTrans: true,
+			Body: map[string]interface{}{"name": "other"}},
+	}
+	if got := names(splitBatches(profiles, 1)); !reflect.DeepEqual(got, []string{"parent", "child", "other"}) {
+		t.Errorf("split profiles = %v", got)
+	}
+	if got := names(splitBatches(profiles, 3)); !reflect.DeepEqual(got, []string{"child", "parent", "other"}) {
+		t.Errorf("profiles are reordered without split: %v", got)
+	}
+
+	deletes := []RestRequest{}
+	for _, r := range profiles {
+		r.Method = "DELETE"
+		deletes = append(deletes, r)
+	}
+	if got := names(splitBatches(deletes, 1)); !reflect.DeepEqual(got, []string{"other", "child", "parent"}) {
+		t.Errorf("split deletes = %v", got)
+	}
+
+	folders := []RestRequest{
+		{Method: "POST", Kind: "sys/folder", ResName: "f2", Partition: "p", Subfolder: "f1", WithTrans: true},
+		{Method: "POST", Kind: "sys/folder", ResName: "f1", Partition: "p", WithTrans: true},
+	}
+	if got := names(splitBatches(folders, 1)); !reflect.DeepEqual(got, []string{"f1", "f2"}) {
+		t.Errorf("split folders = %v", got)
+	}
+}
+
+func TestBIGIPContext_DoRestRequests_batches(t *testing.T) {
+	transId := 0
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/mgmt/tm/transaction":
+			transId++
+			fmt.Fprintf(w, `{"transId":%d}`, transId)
+		case r.Method == "PATCH" && r.URL.Path == "/mgmt/tm/transaction/2":
+			w.WriteHeader(400)
+			fmt.Fprint(w, `{"code":400,"message":"transaction failed:01070734:3: Configuration error: invalid"}`)
+		case r.Method == "PATCH":
+			fmt.Fprint(w, `{"state":"COMPLETED"}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}, WithMaxTransCommands(1))
+	defer done()
+
+	rr := []RestRequest{
+		{Method: "POST", ResUri: "/mgmt/tm/ltm/pool", Kind: "ltm/pool", ResName: "p1", Partition: "p1",
+			Body: map[string]interface{}{"name": "p1"}, WithTrans: true},
+		{Method: "POST", ResUri: "/mgmt/tm/ltm/virtual", Kind: "ltm/virtual", ResName: "vs1", Partition: "p1",
+			Body: map[string]interface{}{"name": "vs1"}, WithTrans: true},
+		{Method: "POST", ResUri: "/mgmt/tm/ltm/virtual", Kind: "ltm/virtual", ResName: "vs2", Partition: "p1",
+			Body: map[string]interface{}{"name": "vs2"}, WithTrans: true},
+	}
+	results, err := bc.DoRestRequestsInBatches(&rr)
+	var berr *BatchError
+	if !errors.As(err, &berr) {
+		t.Fatalf("expected BatchError, got %v", err)
+	}
+	if len(results) != 3 || !results[0].Done || results[1].Done || results[1].Err == nil || results[2].Done || results[2].Err != nil {
+		t.Errorf("unexpected batch results: %+v", results)
+	}
+	if !strings.Contains(err.Error(), "batch 2 of 3 failed, 1 committed") {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+	if transId != 2 {
+		t.Errorf("%d transactions made, want 2", transId)
+	}
+	if _, err := NewBIGIP(bc.URL, WithMaxTransCommands(-1)); err == nil {
+		t.Errorf("negative max transaction commands should fail")
+	}
+}
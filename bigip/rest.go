@@ -1,6 +1,7 @@
 package f5_bigip
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -14,13 +15,12 @@ func (bc *BIGIPContext) Exist(kind, name, partition, subfolder string) (*map[str
 	method := "GET"
 	payload := ""
 	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": bc.Authorization,
+		"Content-Type": "application/json",
 	}
 
 	var bipresp map[string]interface{}
 	// logRequest(method, url, headers, payload)
-	code, resp, err := httpRequest(bc, bc.client, url, method, payload, headers)
+	code, resp, err := httpRequest(bc, url, method, payload, headers)
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +36,7 @@ func (bc *BIGIPContext) Exist(kind, name, partition, subfolder string) (*map[str
 	case 404:
 		return nil, nil
 	default:
-		return nil, fmt.Errorf("error checking %s %s", kind, assertBigipResp20X(code, resp))
+		return nil, fmt.Errorf("error checking %s %w", kind, assertBigipResp(method, url, code, resp))
 	}
 }
 
@@ -44,8 +44,7 @@ func (bc *BIGIPContext) Deploy(kind, name, partition, subfolder string, body map
 	url := bc.URL + fmt.Sprintf("/mgmt/tm/%s", kind)
 	method := "POST"
 	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": bc.Authorization,
+		"Content-Type": "application/json",
 	}
 
 	if partition != "" {
@@ -60,20 +59,19 @@ func (bc *BIGIPContext) Deploy(kind, name, partition, subfolder string, body map
 		return err
 	}
 	payload := string(bbody)
-	code, resp, err := httpRequest(bc, bc.client, url, method, payload, headers)
+	code, resp, err := httpRequest(bc, url, method, payload, headers)
 	if err != nil {
 		return err
 	}
 
-	return assertBigipResp20X(code, resp)
+	return assertBigipResp(method, url, code, resp)
 }
 
 func (bc *BIGIPContext) Update(kind, name, partition, subfolder string, body map[string]interface{}) error {
 	url := bc.URL + fmt.Sprintf("/mgmt/tm/%s/%s", kind, utils.Refname(partition, subfolder, name))
 	method := "PATCH"
 	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": bc.Authorization,
+		"Content-Type": "application/json",
 	}
 
 	bbody, err := json.Marshal(body)
@@ -81,71 +79,39 @@ func (bc *BIGIPContext) Update(kind, name, partition, subfolder string, body map
 		return err
 	}
 	payload := string(bbody)
-	code, resp, err := httpRequest(bc, bc.client, url, method, payload, headers)
+	code, resp, err := httpRequest(bc, url, method, payload, headers)
 	if err != nil {
 		return err
 	}
 
-	return assertBigipResp20X(code, resp)
+	return assertBigipResp(method, url, code, resp)
 }
 
 func (bc *BIGIPContext) Delete(kind, name, partition, subfolder string) error {
 	url := bc.URL + fmt.Sprintf("/mgmt/tm/%s/%s", kind, utils.Refname(partition, subfolder, name))
 	method := "DELETE"
 	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": bc.Authorization,
+		"Content-Type": "application/json",
 	}
 
 	payload := ""
-	code, resp, err := httpRequest(bc, bc.client, url, method, payload, headers)
+	code, resp, err := httpRequest(bc, url, method, payload, headers)
 	if err != nil {
 		return err
 	}
-	return assertBigipResp20X(code, resp)
+	return assertBigipResp(method, url, code, resp)
 }
 
+// Upload uploads content as /var/config/rest/downloads/<name> on BIG-IP, and returns the local file path.
+// Large content is split into chunks, see UploadReader.
 func (bc *BIGIPContext) Upload(name, content string) (string, error) {
-	url := bc.URL + fmt.Sprintf("/mgmt/shared/file-transfer/uploads/%s", name)
-	method := "POST"
-	payload := content
-	length := len(payload)
-	headers := map[string]string{
-		"Content-Type":   "application/octet-stream",
-		"Authorization":  bc.Authorization,
-		"Content-Length": fmt.Sprint(length),
-		"Content-Range":  fmt.Sprintf("0-%d/%d", length-1, length),
-	}
-
-	var bipresp map[string]interface{}
-	// logRequest(method, url, headers, payload)
-	code, resp, err := httpRequest(bc, bc.client, url, method, payload, headers)
-	if err != nil {
-		return "", err
-	}
-
-	switch code {
-	case 200:
-		err = json.Unmarshal(resp, &bipresp)
-		if err != nil {
-			return "", err
-		}
-	default:
-		return "", fmt.Errorf("error uploading %s", assertBigipResp20X(code, resp))
-	}
-
-	if p, f := bipresp["localFilePath"]; f {
-		return p.(string), nil
-	} else {
-		return "", fmt.Errorf("localFilePath field not found")
-	}
+	return bc.UploadReader(name, strings.NewReader(content), int64(len(content)), nil)
 }
 
 func (bc *BIGIPContext) Restcall(endpoint, method string, headers map[string]string, body map[string]interface{}) error {
 	url := bc.URL + endpoint
 	hdrs := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": bc.Authorization,
+		"Content-Type": "application/json",
 	}
 	for k, v := range headers {
 		hdrs[k] = v
@@ -156,12 +122,12 @@ func (bc *BIGIPContext) Restcall(endpoint, method string, headers map[string]str
 		return err
 	}
 	payload := string(bbody)
-	code, resp, err := httpRequest(bc, bc.client, url, method, payload, hdrs)
+	code, resp, err := httpRequest(bc, url, method, payload, hdrs)
 	if err != nil {
 		return err
 	}
 
-	return assertBigipResp20X(code, resp)
+	return assertBigipResp(method, url, code, resp)
 }
 
 func (bc *BIGIPContext) All(kind string) (*map[string]interface{}, error) {
@@ -169,12 +135,11 @@ func (bc *BIGIPContext) All(kind string) (*map[string]interface{}, error) {
 	method := "GET"
 	payload := ""
 	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": bc.Authorization,
+		"Content-Type": "application/json",
 	}
 
 	var bipresp map[string]interface{}
-	code, resp, err := httpRequest(bc, bc.client, url, method, payload, headers)
+	code, resp, err := httpRequest(bc, url, method, payload, headers)
 	if err != nil {
 		return nil, err
 	}
@@ -188,10 +153,12 @@ func (bc *BIGIPContext) All(kind string) (*map[string]interface{}, error) {
 			return &bipresp, nil
 		}
 	default:
-		return nil, fmt.Errorf("error retriving %s %s", kind, assertBigipResp20X(code, resp))
+		return nil, fmt.Errorf("error retriving %s %w", kind, assertBigipResp(method, url, code, resp))
 	}
 }
 
+// Tmsh runs the raw tmsh command line cmd, the commandResult of /mgmt/tm/util/bash is in the response.
+// It's passed to tmsh as is, use Run with NewTmshCommand to build it from untrusted values.
 func (bc *BIGIPContext) Tmsh(cmd string) (*map[string]interface{}, error) {
 	defer utils.TimeItToPrometheus()()
 	slog := utils.LogFromContext(bc.Context)
@@ -201,18 +168,17 @@ func (bc *BIGIPContext) Tmsh(cmd string) (*map[string]interface{}, error) {
 	url := bc.URL + "/mgmt/tm/util/bash"
 	method := "POST"
 	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": bc.Authorization,
+		"Content-Type": "application/json",
 	}
 
 	body := map[string]string{
 		"command":     "run",
-		"utilCmdArgs": fmt.Sprintf("-c 'tmsh -c \"%s\"'", cmd),
+		"utilCmdArgs": "-c " + shellQuote("tmsh -c "+shellQuote(cmd)),
 	}
 	bbody, _ := json.Marshal(body)
 	payload := string(bbody)
 	defer utils.TimeItTrace(slog)("tmsh: %s %s %s", method, url, payload)
-	code, resp, err := httpRequest(bc, bc.client, url, method, payload, headers)
+	code, resp, err := httpRequest(bc, url, method, payload, headers)
 	if err != nil {
 		return nil, err
 	}
@@ -222,7 +188,7 @@ func (bc *BIGIPContext) Tmsh(cmd string) (*map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &jresp, assertBigipResp20X(code, resp)
+	return &jresp, assertBigipResp(method, url, code, resp)
 }
 
 // Members return []interface{} as /mgmt/tm/ltm/pool?expandSubcollections=true returns to us
@@ -234,8 +200,11 @@ func (bc *BIGIPContext) Members(poolname string, partition string, subfolder str
 	if err != nil || mbsp == nil {
 		return mbls, err
 	}
-	mbs := *mbsp
-	return mbs["items"].([]interface{}), nil
+	// items is left out for the pool without members.
+	if items, ok := (*mbsp)["items"].([]interface{}); ok {
+		return items, nil
+	}
+	return mbls, nil
 }
 
 func (bc *BIGIPContext) Arps() (*map[string]string, error) {
@@ -312,19 +281,18 @@ func (bc *BIGIPContext) MakeTrans() (float64, error) {
 	url := bc.URL + "/mgmt/tm/transaction"
 	method := "POST"
 	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": bc.Authorization,
+		"Content-Type": "application/json",
 	}
 
 	body := map[string]interface{}{}
 	bbody, _ := json.Marshal(body)
 	payload := string(bbody)
-	code, resp, err := httpRequest(bc, bc.client, url, method, payload, headers)
+	code, resp, err := httpRequest(bc, url, method, payload, headers)
 	if err != nil {
 		return 0, err
 	}
 
-	if err := assertBigipResp20X(code, resp); err != nil {
+	if err := assertBigipResp(method, url, code, resp); err != nil {
 		return 0, err
 	}
 
@@ -341,20 +309,35 @@ func (bc *BIGIPContext) MakeTrans() (float64, error) {
 }
 
 func (bc *BIGIPContext) DeployWithTrans(rr *[]RestRequest, transId float64) (int, error) {
+	cmds, err := bc.deployWithTrans(rr, transId, false)
+	return len(cmds), err
+}
+
+// deployWithTrans sends the requests, and returns those added into the transaction, in the evalOrder.
+// The requests not in the transaction are skipped if dryRun, because they take effect immediately.
+func (bc *BIGIPContext) deployWithTrans(rr *[]RestRequest, transId float64, dryRun bool) ([]RestRequest, error) {
 	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(bc.Context)
 
 	headersTmpl := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": bc.Authorization,
+		"Content-Type": "application/json",
 	}
 
-	count := 0
+	cmds := []RestRequest{}
 	for _, r := range *rr {
 		// method
 		method := r.Method
 		if method == "NOPE" {
 			continue
 		}
+		// the scheduled ones are run by the syncer after commit.
+		if r.ScheduleIt != "" {
+			continue
+		}
+		if dryRun && !r.WithTrans {
+			slog.Infof("dry run: skipping %s %s %s not in transaction", method, r.Kind, r.ResName)
+			continue
+		}
 
 		// body
 		var bbody []byte
@@ -362,7 +345,7 @@ func (bc *BIGIPContext) DeployWithTrans(rr *[]RestRequest, transId float64) (int
 		if bodyType == "map" {
 			copiedbody, err := utils.DeepCopy(r.Body)
 			if err != nil {
-				return 0, err
+				return nil, err
 			}
 			body := copiedbody.(map[string]interface{})
 			if _, f := body["partition"]; !f {
@@ -373,13 +356,13 @@ func (bc *BIGIPContext) DeployWithTrans(rr *[]RestRequest, transId float64) (int
 			}
 			mbody, err := utils.MarshalNoEscaping(body)
 			if err != nil {
-				return 0, fmt.Errorf("failed to marshal payload: %s, %s", r.ResName, err.Error())
+				return nil, fmt.Errorf("failed to marshal payload: %s, %s", r.ResName, err.Error())
 			}
 			bbody = mbody
 		} else if bodyType == "string" {
 			bbody = []byte(r.Body.(string))
 		} else {
-			return 0, fmt.Errorf("body type is invalid: %s", bodyType)
+			return nil, fmt.Errorf("body type is invalid: %s", bodyType)
 		}
 
 		// url
@@ -401,7 +384,7 @@ func (bc *BIGIPContext) DeployWithTrans(rr *[]RestRequest, transId float64) (int
 			}
 			bbody = []byte{}
 		default:
-			return 0, fmt.Errorf("not support method: %s", method)
+			return nil, fmt.Errorf("not support method: %s", method)
 		}
 
 		// headers
@@ -416,42 +399,68 @@ func (bc *BIGIPContext) DeployWithTrans(rr *[]RestRequest, transId float64) (int
 			headers[hk] = fmt.Sprintf("%v", hv)
 		}
 
+		// uploads are sent in chunks and not a part of the transaction.
+		if method == "POST" && isUploadUri(r.ResUri) {
+			logRequest(bc, method, url, headers, fmt.Sprintf("(%d bytes)", len(bbody)))
+			if _, err := bc.upload(r.ResUri, bytes.NewReader(bbody), int64(len(bbody)), nil); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		// run..
 		logRequest(bc, method, url, headers, string(bbody))
-		code, resp, err := httpRequest(bc, bc.client, url, method, string(bbody), headers)
+		code, resp, err := httpRequest(bc, url, method, string(bbody), headers)
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
-		if err := assertBigipResp20X(code, resp); err != nil {
-			return 0, err
+		if err := assertBigipResp(method, url, code, resp); err != nil {
+			return nil, err
 		}
 		if r.WithTrans {
-			count += 1
+			cmds = append(cmds, r)
 		}
 	}
-	return count, nil
+	return cmds, nil
 }
 
 func (bc *BIGIPContext) CommitTrans(transId float64) error {
 	defer utils.TimeItToPrometheus()()
-	payload, _ := json.Marshal(map[string]interface{}{
+	return bc.submitTrans(transId, false)
+}
+
+// ValidateTrans asks BIG-IP to validate the transaction without committing it,
+// nil is returned if the commands would succeed. The transaction is kept, delete it with DeleteTrans.
+func (bc *BIGIPContext) ValidateTrans(transId float64) error {
+	defer utils.TimeItToPrometheus()()
+	return bc.submitTrans(transId, true)
+}
+
+func (bc *BIGIPContext) submitTrans(transId float64, validateOnly bool) error {
+	body := map[string]interface{}{
 		"state": "VALIDATING",
-	})
+	}
+	expected := "COMPLETED"
+	if validateOnly {
+		body["validateOnly"] = true
+		expected = "VALIDATION_SUCCEEDED"
+	}
+	payload, _ := json.Marshal(body)
+	url := bc.URL + "/mgmt/tm/transaction/" + fmt.Sprintf("%.f", transId)
+	method := "PATCH"
 	code, resp, err := httpRequest(
 		bc,
-		bc.client,
-		bc.URL+"/mgmt/tm/transaction/"+fmt.Sprintf("%.f", transId),
-		"PATCH",
+		url,
+		method,
 		string(payload),
 		map[string]string{
-			"Content-Type":  "application/json",
-			"Authorization": bc.Authorization,
+			"Content-Type": "application/json",
 		},
 	)
 	if err != nil {
 		return err
 	}
-	if err := assertBigipResp20X(code, resp); err != nil {
+	if err := assertBigipResp(method, url, code, resp); err != nil {
 		return err
 	}
 
@@ -462,7 +471,7 @@ func (bc *BIGIPContext) CommitTrans(transId float64) error {
 		if result, f := jresp["state"]; !f {
 			return fmt.Errorf("strange.. not found state from transaction response: %s", resp)
 		} else {
-			if result.(string) == "COMPLETED" {
+			if result.(string) == expected {
 				return nil
 			} else {
 				return fmt.Errorf("%s", resp)
@@ -470,3 +479,18 @@ func (bc *BIGIPContext) CommitTrans(transId float64) error {
 		}
 	}
 }
+
+// DeleteTrans deletes the transaction with all the commands in it, it's not committed any more.
+func (bc *BIGIPContext) DeleteTrans(transId float64) error {
+	url := bc.URL + "/mgmt/tm/transaction/" + fmt.Sprintf("%.f", transId)
+	method := "DELETE"
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	code, resp, err := httpRequest(bc, url, method, "", headers)
+	if err != nil {
+		return err
+	}
+	return assertBigipResp(method, url, code, resp)
+}
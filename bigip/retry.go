@@ -0,0 +1,76 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// withRetry calls f until it succeeds, fails with a non-retryable error, the retry policy is exhausted,
+// or bc.Context is done. The last error is returned.
+func (bc *BIGIPContext) withRetry(name string, f func() error) error {
+	return bc.retryWith(bc.retry, name, f)
+}
+
+// retryWith does the same as withRetry, but follows the given policy instead of bc.retry.
+func (bc *BIGIPContext) retryWith(policy RetryPolicy, name string, f func() error) error {
+	slog := utils.LogFromContext(bc.Context)
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || !utils.NeedRetry(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+		wait := policy.backoff(attempt)
+		if policy.Deadline > 0 && time.Since(start)+wait > policy.Deadline {
+			slog.Warnf("%s: giving up retrying after %d attempts: deadline %s exceeded", name, attempt, policy.Deadline)
+			return err
+		}
+		slog.Debugf("%s: attempt %d failed, retrying in %s: %s", name, attempt, wait, err.Error())
+		select {
+		case <-bc.ctx().Done():
+			return fmt.Errorf("%s: retrying aborted: %w, last error: %s", name, bc.ctx().Err(), err.Error())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// backoff returns the wait before the next attempt, exponential with jitter:
+// a random duration between the half and the full of InitialBackoff*2^(attempt-1), capped by MaxBackoff.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// idempotent tells if the request of the method can be sent again after a 500 response,
+// which doesn't tell if the request has been applied. POST and PATCH are resent on 401 and 503 only.
+func idempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "PUT", "DELETE", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+// inTransaction tells if the request is a part of a transaction, which must not be replayed alone:
+// a failed command leaves the transaction dead, the whole transaction should be restarted instead.
+func inTransaction(url string, headers map[string]string) bool {
+	if _, f := headers["X-F5-REST-Coordination-Id"]; f {
+		return true
+	}
+	return strings.Contains(url, "/mgmt/tm/transaction/")
+}
@@ -0,0 +1,129 @@
+package f5_bigip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+func Test_RetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if got := p.backoff(tt.attempt); got < tt.max/2 || got > tt.max {
+				t.Errorf("backoff(%d) = %s, want between %s and %s", tt.attempt, got, tt.max/2, tt.max)
+			}
+		}
+	}
+	if got := (RetryPolicy{}).backoff(3); got != 0 {
+		t.Errorf("backoff of zero policy = %s, want 0", got)
+	}
+}
+
+func Test_withRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		err      error
+		failures int
+		attempts int
+	}{
+		{"no retry with zero policy", RetryPolicy{}, utils.RetryErrorf("503"), 10, 1},
+		{"retry until success", RetryPolicy{MaxAttempts: 5}, utils.RetryErrorf("503"), 2, 3},
+		{"retry until exhausted", RetryPolicy{MaxAttempts: 3}, utils.RetryErrorf("503"), 10, 3},
+		{"not retryable", RetryPolicy{MaxAttempts: 5}, fmt.Errorf("409"), 10, 1},
+		{"deadline exceeded", RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, Deadline: time.Millisecond}, utils.RetryErrorf("503"), 10, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := &BIGIPContext{BIGIP: BIGIP{retry: tt.policy}, Context: context.TODO()}
+			attempts := 0
+			bc.withRetry("test", func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+			if attempts != tt.attempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func Test_inTransaction(t *testing.T) {
+	if !inTransaction("https://1.2.3.4/mgmt/tm/ltm/pool", map[string]string{"X-F5-REST-Coordination-Id": "1"}) {
+		t.Errorf("command with coordination id should be in transaction")
+	}
+	if !inTransaction("https://1.2.3.4/mgmt/tm/transaction/1", map[string]string{}) {
+		t.Errorf("committing should be in transaction")
+	}
+	if inTransaction("https://1.2.3.4/mgmt/tm/transaction", map[string]string{}) {
+		t.Errorf("making transaction should not be in transaction")
+	}
+}
+
+func Test_httpRequest_retry(t *testing.T) {
+	tests := []struct {
+		method   string
+		code     int
+		attempts int
+	}{
+		{"GET", 500, 3},
+		{"DELETE", 500, 3},
+		{"POST", 500, 1},
+		{"PATCH", 500, 1},
+		{"POST", 503, 3},
+		{"PATCH", 503, 3},
+		{"POST", 409, 1},
+	}
+	for _, tt := range tests {
+		attempts := 0
+		bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(tt.code)
+			fmt.Fprintf(w, `{"code":%d,"message":"failed"}`, tt.code)
+		}, WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+		code, _, err := httpRequest(bc, bc.URL+"/mgmt/tm/ltm/pool", tt.method, "", map[string]string{})
+		done()
+		if err != nil || code != tt.code {
+			t.Errorf("%s %d: code = %d, err = %v", tt.method, tt.code, code, err)
+		}
+		if attempts != tt.attempts {
+			t.Errorf("%s %d: attempts = %d, want %d", tt.method, tt.code, attempts, tt.attempts)
+		}
+	}
+}
+
+func TestNewBIGIP_defaultRetryPolicy(t *testing.T) {
+	bip, err := NewBIGIP("https://192.0.2.1", WithBasicAuth("admin", "admin"), WithLazyVersion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bip.retry != DefaultRetryPolicy {
+		t.Errorf("retry = %+v, want %+v", bip.retry, DefaultRetryPolicy)
+	}
+	bip, err = NewBIGIP("https://192.0.2.1", WithBasicAuth("admin", "admin"), WithLazyVersion(), WithRetryPolicy(RetryPolicy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bip.retry != (RetryPolicy{}) {
+		t.Errorf("retry = %+v, want no retry", bip.retry)
+	}
+}
@@ -0,0 +1,219 @@
+package f5_bigip
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// Stats returns the statistics of <kind>/<name>/stats, or <kind>/stats if name is empty,
+// keyed by the full paths of the resources, like "/Common/vs1".
+func (bc *BIGIPContext) Stats(kind, name, partition, subfolder string) (map[string]*Stats, error) {
+	defer utils.TimeItToPrometheus()()
+
+	uri := uriname(kind, "stats")
+	if name != "" {
+		uri = uriname(kind, utils.Refname(partition, subfolder, name), "stats")
+	}
+	url := bc.URL + "/mgmt/tm/" + uri
+	method := "GET"
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	code, resp, err := httpRequest(bc, url, method, "", headers)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBigipResp(method, url, code, resp); err != nil {
+		return nil, fmt.Errorf("error retriving %s stats %w", kind, err)
+	}
+
+	// counters may exceed the precision of float64.
+	var jresp map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(resp))
+	decoder.UseNumber()
+	if err := decoder.Decode(&jresp); err != nil {
+		return nil, err
+	}
+	return flattenStats(jresp)
+}
+
+// VirtualStats returns the statistics of the ltm virtual.
+func (bc *BIGIPContext) VirtualStats(name, partition, subfolder string) (*VirtualStats, error) {
+	s, fullPath, err := bc.singleStats("ltm/virtual", name, partition, subfolder)
+	if err != nil {
+		return nil, err
+	}
+	return &VirtualStats{
+		FullPath:          fullPath,
+		AvailabilityState: s.Descriptions["status.availabilityState"],
+		EnabledState:      s.Descriptions["status.enabledState"],
+		StatusReason:      s.Descriptions["status.statusReason"],
+		BitsIn:            s.Counters["clientside.bitsIn"],
+		BitsOut:           s.Counters["clientside.bitsOut"],
+		PktsIn:            s.Counters["clientside.pktsIn"],
+		PktsOut:           s.Counters["clientside.pktsOut"],
+		CurConns:          s.Counters["clientside.curConns"],
+		MaxConns:          s.Counters["clientside.maxConns"],
+		TotConns:          s.Counters["clientside.totConns"],
+	}, nil
+}
+
+// PoolStats returns the statistics of the ltm pool.
+func (bc *BIGIPContext) PoolStats(name, partition, subfolder string) (*PoolStats, error) {
+	s, fullPath, err := bc.singleStats("ltm/pool", name, partition, subfolder)
+	if err != nil {
+		return nil, err
+	}
+	return &PoolStats{
+		FullPath:          fullPath,
+		AvailabilityState: s.Descriptions["status.availabilityState"],
+		EnabledState:      s.Descriptions["status.enabledState"],
+		StatusReason:      s.Descriptions["status.statusReason"],
+		ActiveMemberCnt:   s.Counters["activeMemberCnt"],
+		BitsIn:            s.Counters["serverside.bitsIn"],
+		BitsOut:           s.Counters["serverside.bitsOut"],
+		PktsIn:            s.Counters["serverside.pktsIn"],
+		PktsOut:           s.Counters["serverside.pktsOut"],
+		CurConns:          s.Counters["serverside.curConns"],
+		MaxConns:          s.Counters["serverside.maxConns"],
+		TotConns:          s.Counters["serverside.totConns"],
+		CurSessions:       s.Counters["curSessions"],
+	}, nil
+}
+
+// PoolMemberStats returns the statistics of all the members of the ltm pool, sorted by full path.
+func (bc *BIGIPContext) PoolMemberStats(poolname, partition, subfolder string) ([]PoolMemberStats, error) {
+	stats, err := bc.Stats("ltm/pool", poolname+"/members", partition, subfolder)
+	if err != nil {
+		return nil, err
+	}
+	mstats := []PoolMemberStats{}
+	for fullPath, s := range stats {
+		mstats = append(mstats, PoolMemberStats{
+			FullPath:          fullPath,
+			Address:           s.Descriptions["addr"],
+			Port:              s.Counters["port"],
+			NodeName:          s.Descriptions["nodeName"],
+			AvailabilityState: s.Descriptions["status.availabilityState"],
+			EnabledState:      s.Descriptions["status.enabledState"],
+			SessionStatus:     s.Descriptions["sessionStatus"],
+			StatusReason:      s.Descriptions["status.statusReason"],
+			BitsIn:            s.Counters["serverside.bitsIn"],
+			BitsOut:           s.Counters["serverside.bitsOut"],
+			PktsIn:            s.Counters["serverside.pktsIn"],
+			PktsOut:           s.Counters["serverside.pktsOut"],
+			CurConns:          s.Counters["serverside.curConns"],
+			MaxConns:          s.Counters["serverside.maxConns"],
+			TotConns:          s.Counters["serverside.totConns"],
+			CurSessions:       s.Counters["curSessions"],
+		})
+	}
+	sort.Slice(mstats, func(i, j int) bool { return mstats[i].FullPath < mstats[j].FullPath })
+	return mstats, nil
+}
+
+func (bc *BIGIPContext) singleStats(kind, name, partition, subfolder string) (*Stats, string, error) {
+	stats, err := bc.Stats(kind, name, partition, subfolder)
+	if err != nil {
+		return nil, "", err
+	}
+	for fullPath, s := range stats {
+		return s, fullPath, nil
+	}
+	return nil, "", fmt.Errorf("stats of %s %s not found", kind, name)
+}
+
+// flattenStats flattens the response of a stats request.
+// The collection responses have one nestedStats for each resource, keyed by their selfLinks,
+// while the single resource response of old BIG-IP versions has the entries directly.
+func flattenStats(jresp map[string]interface{}) (map[string]*Stats, error) {
+	entries, ok := jresp["entries"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("entries not found")
+	}
+
+	stats := map[string]*Stats{}
+	for k, v := range entries {
+		if nested := nestedStatsEntries(v); nested != nil && strings.Contains(k, "/mgmt/tm/") {
+			s := &Stats{Counters: map[string]uint64{}, Descriptions: map[string]string{}}
+			flattenStatsEntries(nested, "", s)
+			stats[statsPath(k)] = s
+		}
+	}
+	if len(stats) == 0 {
+		s := &Stats{Counters: map[string]uint64{}, Descriptions: map[string]string{}}
+		flattenStatsEntries(entries, "", s)
+		selfLink, _ := jresp["selfLink"].(string)
+		stats[statsPath(selfLink)] = s
+	}
+	return stats, nil
+}
+
+func flattenStatsEntries(entries map[string]interface{}, prefix string, s *Stats) {
+	for k, v := range entries {
+		if nested := nestedStatsEntries(v); nested != nil {
+			flattenStatsEntries(nested, prefix+statsPath(k)+".", s)
+			continue
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if description, f := m["description"].(string); f {
+			s.Descriptions[prefix+k] = description
+		}
+		if value, f := statsCounter(m["value"]); f {
+			s.Counters[prefix+k] = value
+		}
+	}
+}
+
+func nestedStatsEntries(v interface{}) map[string]interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	nestedStats, ok := m["nestedStats"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	entries, _ := nestedStats["entries"].(map[string]interface{})
+	return entries
+}
+
+func statsCounter(v interface{}) (uint64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+			return i, true
+		}
+		if f, err := n.Float64(); err == nil && f >= 0 {
+			return uint64(f), true
+		}
+	case float64:
+		if n >= 0 {
+			return uint64(n), true
+		}
+	}
+	return 0, false
+}
+
+// statsPath converts the stats link like "https://localhost/mgmt/tm/ltm/virtual/~Common~vs1/stats?ver=16.1.0"
+// to the full path of the resource "/Common/vs1".
+func statsPath(link string) string {
+	link = strings.Split(link, "?")[0]
+	link = strings.TrimSuffix(link, "/stats")
+	name := link[strings.LastIndex(link, "/")+1:]
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	return strings.ReplaceAll(name, "~", "/")
+}
@@ -0,0 +1,104 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func Test_bigipVersion(t *testing.T) {
+	sysinfo := map[string]interface{}{}
+	json.Unmarshal([]byte(`{
+		"kind": "tm:sys:version:versionstats",
+		"selfLink": "https://localhost/mgmt/tm/sys/version?ver=16.1.0",
+		"entries": {
+			"https://localhost/mgmt/tm/sys/version/0": {
+				"nestedStats": {
+					"entries": {
+						"Build": {"description": "0.0.19"},
+						"Product": {"description": "BIG-IP"},
+						"Version": {"description": "16.1.0"}
+					}
+				}
+			}
+		}
+	}`), &sysinfo)
+	if version, err := bigipVersion(sysinfo); err != nil || version != "16.1.0" {
+		t.Errorf("bigipVersion() = %s, %v", version, err)
+	}
+	if _, err := bigipVersion(map[string]interface{}{}); err == nil {
+		t.Errorf("bigipVersion() should fail without entries")
+	}
+}
+
+func Test_flattenStats(t *testing.T) {
+	jresp := map[string]interface{}{}
+	json.Unmarshal([]byte(`{
+		"selfLink": "https://localhost/mgmt/tm/ltm/virtual/~Common~vs1/stats?ver=12.1.0",
+		"entries": {
+			"clientside.curConns": {"value": 3},
+			"status.availabilityState": {"description": "available"},
+			"profiles": {
+				"nestedStats": {
+					"entries": {
+						"https://localhost/mgmt/tm/ltm/virtual/~Common~vs1/profiles/~Common~http/stats": {
+							"nestedStats": {"entries": {"requests": {"value": 10}}}
+						}
+					}
+				}
+			}
+		}
+	}`), &jresp)
+	stats, err := flattenStats(jresp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, f := stats["/Common/vs1"]
+	if !f {
+		t.Fatalf("stats of /Common/vs1 not found: %v", stats)
+	}
+	if s.Counters["clientside.curConns"] != 3 || s.Descriptions["status.availabilityState"] != "available" {
+		t.Errorf("unexpected stats: %v", s)
+	}
+	if s.Counters["profiles./Common/http.requests"] != 10 {
+		t.Errorf("deeper nestedStats not flattened: %v", s.Counters)
+	}
+}
+
+func TestBIGIPContext_PoolMemberStats(t *testing.T) {
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mgmt/tm/ltm/pool/~Common~pool1/members/stats" {
+			w.WriteHeader(404)
+			return
+		}
+		entries := []string{}
+		for i, conns := range []string{"18446744073709551615", "0"} {
+			entries = append(entries, fmt.Sprintf(`"https://localhost/mgmt/tm/ltm/pool/~Common~pool1/members/~Common~10.0.0.%d:80/stats": {
+				"nestedStats": {"entries": {
+					"addr": {"description": "10.0.0.%d"},
+					"port": {"value": 80},
+					"sessionStatus": {"description": "enabled"},
+					"serverside.curConns": {"value": %s}
+				}}
+			}`, i+1, i+1, conns))
+		}
+		fmt.Fprintf(w, `{"entries": {%s, %s}}`, entries[0], entries[1])
+	})
+	defer done()
+
+	mstats, err := bc.PoolMemberStats("pool1", "Common", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mstats) != 2 {
+		t.Fatalf("got %d members, want 2", len(mstats))
+	}
+	m := mstats[0]
+	if m.FullPath != "/Common/10.0.0.1:80" || m.Address != "10.0.0.1" || m.Port != 80 || m.SessionStatus != "enabled" {
+		t.Errorf("unexpected member stats: %+v", m)
+	}
+	if m.CurConns != 18446744073709551615 {
+		t.Errorf("counter lost precision: %d", m.CurConns)
+	}
+}
@@ -0,0 +1,311 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+func newResSyncer() *resSyncer {
+	return &resSyncer{
+		results: map[string]*ScheduledResult{},
+		stops:   map[string]chan struct{}{},
+	}
+}
+
+// ScheduledResults reports the rest requests with ScheduleIt handed to the syncer, sorted by their resources.
+func (bc *BIGIPContext) ScheduledResults() []ScheduledResult {
+	if bc.syncer == nil {
+		return []ScheduledResult{}
+	}
+	bc.syncer.mutex.Lock()
+	defer bc.syncer.mutex.Unlock()
+
+	keys := []string{}
+	for k := range bc.syncer.results {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	results := []ScheduledResult{}
+	for _, k := range keys {
+		results = append(results, *bc.syncer.results[k])
+	}
+	return results
+}
+
+// StopScheduled stops the periodic runs of the "@every" and cron requests, the ones running are not interrupted.
+func (bc *BIGIPContext) StopScheduled() {
+	if bc.syncer == nil {
+		return
+	}
+	bc.syncer.mutex.Lock()
+	defer bc.syncer.mutex.Unlock()
+
+	for k, stop := range bc.syncer.stops {
+		close(stop)
+		delete(bc.syncer.stops, k)
+	}
+}
+
+// schedule hands the requests with ScheduleIt to the syncer, it's called after their transaction is committed.
+// The requests are run in background with a context detached from bc, keeping the values like the logger.
+func (bc *BIGIPContext) schedule(rr []RestRequest) {
+	slog := utils.LogFromContext(bc.Context)
+	for _, r := range rr {
+		if r.ScheduleIt == "" {
+			continue
+		}
+		if bc.syncer == nil {
+			slog.Warnf("no syncer of BIGIP created by NewBIGIP, dropping scheduled %s %s %s", r.Method, r.Kind, r.ResName)
+			continue
+		}
+		bc.syncer.add(&BIGIPContext{BIGIP: bc.BIGIP, Context: valueOnlyContext{bc.ctx()}}, r)
+	}
+}
+
+func (s *resSyncer) add(bc *BIGIPContext, r RestRequest) {
+	slog := utils.LogFromContext(bc.Context)
+	key := scheduledKey(r)
+	result := &ScheduledResult{Request: r}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// a newer one replaces the former, stopping it if it's periodic.
+	if stop, f := s.stops[key]; f {
+		close(stop)
+		delete(s.stops, key)
+	}
+	s.results[key] = result
+
+	delay, every, cron, err := parseSchedule(r.ScheduleIt)
+	if err != nil {
+		slog.Errorf("invalid schedule of %s %s %s: %s", r.Method, r.Kind, r.ResName, err.Error())
+		result.Err = err
+		s.finish(result)
+		return
+	}
+	if delay < 0 {
+		slog.Debugf("skipping %s %s %s scheduled %s", r.Method, r.Kind, r.ResName, r.ScheduleIt)
+		result.Skipped = true
+		s.finish(result)
+		return
+	}
+
+	stop := make(chan struct{})
+	periodic := every > 0 || cron != nil
+	if periodic {
+		s.stops[key] = stop
+	}
+	go func() {
+		defer utils.HandleCrash(slog)
+		wait := delay
+		if cron != nil {
+			wait = time.Until(cron.next(time.Now()))
+		}
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(wait):
+			}
+			err := bc.runScheduled(r, stop)
+			if err != nil {
+				slog.Errorf("failed to run scheduled %s %s %s: %s", r.Method, r.Kind, r.ResName, err.Error())
+			}
+			s.mutex.Lock()
+			result.Runs++
+			result.LastRun = time.Now()
+			result.Err = err
+			if !periodic {
+				s.finish(result)
+			}
+			s.mutex.Unlock()
+			if !periodic {
+				return
+			}
+			wait = every
+			if cron != nil {
+				wait = time.Until(cron.next(time.Now()))
+			}
+		}
+	}()
+}
+
+// finish keeps the finished result of a one-shot request, dropping the oldest ones beyond maxFinishedResults.
+// It's called with the mutex locked.
+func (s *resSyncer) finish(result *ScheduledResult) {
+	s.finished = append(s.finished, result)
+	for len(s.finished) > maxFinishedResults {
+		oldest := s.finished[0]
+		s.finished = s.finished[1:]
+		// the key may be reused by a newer request since.
+		if key := scheduledKey(oldest.Request); s.results[key] == oldest {
+			delete(s.results, key)
+		}
+	}
+}
+
+// runScheduled sends the request, retrying it following scheduledRetryPolicy unless stopped.
+func (bc *BIGIPContext) runScheduled(r RestRequest, stop <-chan struct{}) error {
+	slog := utils.LogFromContext(bc.Context)
+	r.ScheduleIt = ""
+	var err error
+	for attempt := 1; ; attempt++ {
+		dbc, cancel := bc.detached(scheduledRunTimeout)
+		_, err = dbc.deployWithTrans(&[]RestRequest{r}, 0, false)
+		cancel()
+		if err == nil || attempt >= scheduledRetryPolicy.MaxAttempts {
+			return err
+		}
+		wait := scheduledRetryPolicy.backoff(attempt)
+		slog.Debugf("scheduled %s %s %s: attempt %d failed, retrying in %s: %s", r.Method, r.Kind, r.ResName, attempt, wait, err.Error())
+		select {
+		case <-stop:
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// parseSchedule parses ScheduleIt into the delay of the first run after commit, and the interval of the later runs,
+// or into the cron spec giving the time of each run. A negative delay means never.
+func parseSchedule(schedule string) (delay, every time.Duration, cron *cronSpec, err error) {
+	switch {
+	case schedule == "never":
+		return -1, 0, nil, nil
+	case schedule == "after-commit":
+		return 0, 0, nil, nil
+	case strings.HasPrefix(schedule, "@every "):
+		every, err = time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(schedule, "@every ")))
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if every <= 0 {
+			return 0, 0, nil, fmt.Errorf("invalid interval: %s", schedule)
+		}
+		return every, every, nil, nil
+	case len(strings.Fields(schedule)) == 5:
+		cron, err = parseCron(schedule)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		return 0, 0, cron, nil
+	default:
+		delay, err = time.ParseDuration(schedule)
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("not support schedule: %s", schedule)
+		}
+		if delay < 0 {
+			return 0, 0, nil, fmt.Errorf("invalid delay: %s", schedule)
+		}
+		return delay, 0, nil, nil
+	}
+}
+
+// parseCron parses the standard 5 fields of cron: minute, hour, day of month, month and day of week.
+// Each field is "*" or a list of values and ranges like "1,5-10", with an optional step like "*/15".
+func parseCron(schedule string) (*cronSpec, error) {
+	fields := strings.Fields(schedule)
+	limits := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	bits := [5]uint64{}
+	for i, field := range fields {
+		b, err := parseCronField(field, limits[i][0], limits[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %s: %w", schedule, err)
+		}
+		bits[i] = b
+	}
+	// both 0 and 7 are Sunday.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	cron := &cronSpec{
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   bits[4],
+		anyDay:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}
+	if cron.next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid cron %s: never matched", schedule)
+	}
+	return cron, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	bits := uint64(0)
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step: %s", part)
+			}
+			rng, step = part[:i], s
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			var err error
+			bounds := strings.SplitN(rng, "-", 2)
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value: %s", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value: %s", part)
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("out of range %d-%d: %s", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first matched minute after t, or the zero time if nothing matches in 5 years.
+func (c *cronSpec) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		y, m, d := t.Date()
+		switch {
+		case c.months&(1<<uint(m)) == 0:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatched(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+		case c.hours&(1<<uint(t.Hour())) == 0:
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatched follows cron: if both day of month and day of week are restricted, either of them matches.
+func (c *cronSpec) dayMatched(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+func scheduledKey(r RestRequest) string {
+	return strings.Join([]string{r.Method, r.ResUri, r.Kind, utils.Keyname(r.Partition, r.Subfolder, r.ResName)}, " ")
+}
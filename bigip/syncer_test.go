@@ -0,0 +1,168 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_parseSchedule(t *testing.T) {
+	tests := []struct {
+		schedule string
+		delay    time.Duration
+		every    time.Duration
+		cron     bool
+		wantErr  bool
+	}{
+		{schedule: "never", delay: -1},
+		{schedule: "after-commit"},
+		{schedule: "30s", delay: 30 * time.Second},
+		{schedule: "@every 1m", delay: time.Minute, every: time.Minute},
+		{schedule: "@every 0s", wantErr: true},
+		{schedule: "-1s", wantErr: true},
+		{schedule: "tomorrow", wantErr: true},
+		{schedule: "0 3 * * *", cron: true},
+		{schedule: "*/15 1-5,22 1 */2 0-7", cron: true},
+		{schedule: "60 * * * *", wantErr: true},
+		{schedule: "* * * * 1-x", wantErr: true},
+		{schedule: "*/0 * * * *", wantErr: true},
+		{schedule: "0 0 30 2 *", wantErr: true},
+	}
+	for _, tt := range tests {
+		delay, every, cron, err := parseSchedule(tt.schedule)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSchedule(%s) error = %v, wantErr %v", tt.schedule, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (delay != tt.delay || every != tt.every || (cron != nil) != tt.cron) {
+			t.Errorf("parseSchedule(%s) = %s, %s, %v, want %s, %s, cron %v", tt.schedule, delay, every, cron, tt.delay, tt.every, tt.cron)
+		}
+	}
+}
+
+func Test_cronSpec_next(t *testing.T) {
+	now := time.Date(2023, 1, 31, 10, 20, 30, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		next     time.Time
+	}{
+		{schedule: "* * * * *", next: time.Date(2023, 1, 31, 10, 21, 0, 0, time.UTC)},
+		{schedule: "*/15 * * * *", next: time.Date(2023, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{schedule: "0 3 * * *", next: time.Date(2023, 2, 1, 3, 0, 0, 0, time.UTC)},
+		{schedule: "20 10 * * *", next: time.Date(2023, 2, 1, 10, 20, 0, 0, time.UTC)},
+		{schedule: "0 0 1 */3 *", next: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 29 2 *", next: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// 2023-02-05 is Sunday.
+		{schedule: "30 8 * * 7", next: time.Date(2023, 2, 5, 8, 30, 0, 0, time.UTC)},
+		// either day of month or day of week if both are restricted, 2023-02-03 is Friday.
+		{schedule: "0 0 15 * 5", next: time.Date(2023, 2, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		cron, err := parseCron(tt.schedule)
+		if err != nil {
+			t.Errorf("parseCron(%s) error = %v", tt.schedule, err)
+			continue
+		}
+		if got := cron.next(now); !got.Equal(tt.next) {
+			t.Errorf("next of %s = %s, want %s", tt.schedule, got, tt.next)
+		}
+	}
+}
+
+func Test_resSyncer_finish(t *testing.T) {
+	defer func(n int) { maxFinishedResults = n }(maxFinishedResults)
+	maxFinishedResults = 2
+
+	s := newResSyncer()
+	results := []*ScheduledResult{}
+	for _, n := range []string{"f1", "f2", "f1", "f3"} {
+		r := &ScheduledResult{Request: RestRequest{Method: "POST", ResName: n}}
+		results = append(results, r)
+		s.results[scheduledKey(r.Request)] = r
+	}
+	// f1 is reused by a newer request, which is still kept.
+	for _, r := range results[:2] {
+		s.finish(r)
+	}
+	s.finish(results[3])
+	if len(s.results) != 3 || s.results[scheduledKey(results[2].Request)] != results[2] {
+		t.Errorf("unexpected results: %v", s.results)
+	}
+	s.finish(results[2])
+	if len(s.results) != 2 || s.results[scheduledKey(results[1].Request)] != nil {
+		t.Errorf("unexpected results: %v", s.results)
+	}
+}
+
+func TestBIGIPContext_DoRestRequests_scheduled(t *testing.T) {
+	defer func(p RetryPolicy) { scheduledRetryPolicy = p }(scheduledRetryPolicy)
+	scheduledRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	var mutex sync.Mutex
+	requests := []string{}
+	failed := false
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/mgmt/tm/transaction":
+			requests = append(requests, "make")
+			fmt.Fprint(w, `{"transId":1234}`)
+		case r.Method == "PATCH" && r.URL.Path == "/mgmt/tm/transaction/1234":
+			requests = append(requests, "commit")
+			fmt.Fprint(w, `{"state":"COMPLETED"}`)
+		case r.URL.Path == "/mgmt/tm/util/bash":
+			body := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if !failed {
+				failed = true
+				w.WriteHeader(400)
+				fmt.Fprint(w, `{"code":400,"message":"failed"}`)
+				return
+			}
+			requests = append(requests, fmt.Sprintf("%v", body["utilCmdArgs"]))
+			fmt.Fprint(w, `{}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	})
+	defer done()
+
+	rr := []RestRequest{
+		{Method: "POST", ResUri: "/mgmt/tm/ltm/pool", Kind: "ltm/pool", ResName: "pool1", Partition: "p1",
+			Body: map[string]interface{}{"name": "pool1"}, WithTrans: true},
+		bc.constructUploadCleanup("f1", "p1", "", "after-commit"),
+		bc.constructUploadCleanup("f2", "p1", "", "never"),
+	}
+	if err := bc.DoRestRequests(&rr); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []ScheduledResult
+	for i := 0; i < 100; i++ {
+		results = bc.ScheduledResults()
+		if len(results) == 2 && results[0].Runs > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(results) != 2 {
+		t.Fatalf("unexpected scheduled results: %+v", results)
+	}
+	if results[0].Request.ResName != "f1" || results[0].Runs != 1 || results[0].Err != nil {
+		t.Errorf("unexpected result of after-commit: %+v", results[0])
+	}
+	if results[1].Request.ResName != "f2" || !results[1].Skipped || results[1].Runs != 0 {
+		t.Errorf("unexpected result of never: %+v", results[1])
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(requests) != 3 || requests[1] != "commit" || !strings.Contains(requests[2], "/var/config/rest/downloads/f1") {
+		t.Errorf("requests = %v", requests)
+	}
+}
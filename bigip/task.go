@@ -0,0 +1,113 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// RunTask runs the command body as an asynchronous task of /mgmt/tm/task/<kind>, like "sys/config", "sys/ucs"
+// or "cli/script", and waits until it's finished. The result of the task is returned.
+// Tasks are not limited by the timeout of a single iControl call, but by bc.Context,
+// the task is cancelled once bc.Context is done.
+func (bc *BIGIPContext) RunTask(kind string, body map[string]interface{}) (map[string]interface{}, error) {
+	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(bc.Context)
+
+	taskId, err := bc.StartTask(kind, body)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		dbc, cancel := bc.detached(taskCleanupTimeout)
+		defer cancel()
+		if err := dbc.DeleteTask(kind, taskId); err != nil {
+			slog.Warnf("failed to clean up task %s %s: %s", kind, taskId, err.Error())
+		}
+	}()
+	return bc.WaitTask(kind, taskId)
+}
+
+// StartTask creates the task of /mgmt/tm/task/<kind> with the command body and starts it, the task id is returned.
+func (bc *BIGIPContext) StartTask(kind string, body map[string]interface{}) (string, error) {
+	jresp, err := bc.requestJSON("POST", "/mgmt/tm/task/"+kind, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create task %s: %w", kind, err)
+	}
+	id, f := jresp["_taskId"]
+	if !f {
+		return "", fmt.Errorf("strange.. _taskId not found from %v", jresp)
+	}
+	taskId := fmt.Sprintf("%v", id)
+	if _, err := bc.requestJSON("PUT", taskUri(kind, taskId), map[string]interface{}{"_taskState": "VALIDATING"}); err != nil {
+		return "", fmt.Errorf("failed to start task %s %s: %w", kind, taskId, err)
+	}
+	return taskId, nil
+}
+
+// TaskState returns the state of the task, like "STARTED", "COMPLETED" and "FAILED",
+// with the status message if there is.
+func (bc *BIGIPContext) TaskState(kind, taskId string) (string, string, error) {
+	jresp, err := bc.requestJSON("GET", taskUri(kind, taskId), nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get status of task %s %s: %w", kind, taskId, err)
+	}
+	state, _ := jresp["_taskState"].(string)
+	message, _ := jresp["_taskResultMessage"].(string)
+	return state, message, nil
+}
+
+// WaitTask polls the task with backoff until it's finished, and returns the result of it.
+// The task is cancelled if bc.Context is done before that.
+func (bc *BIGIPContext) WaitTask(kind, taskId string) (map[string]interface{}, error) {
+	slog := utils.LogFromContext(bc.Context)
+
+	for attempt := 1; ; attempt++ {
+		state, message, err := bc.TaskState(kind, taskId)
+		if err != nil && bc.ctx().Err() == nil {
+			return nil, err
+		}
+		switch state {
+		case "COMPLETED":
+			return bc.TaskResult(kind, taskId)
+		case "FAILED":
+			return nil, fmt.Errorf("task %s %s failed: %s", kind, taskId, message)
+		}
+		select {
+		case <-bc.ctx().Done():
+			dbc, cancel := bc.detached(taskCleanupTimeout)
+			defer cancel()
+			if err := dbc.CancelTask(kind, taskId); err != nil {
+				slog.Warnf("failed to cancel task %s %s: %s", kind, taskId, err.Error())
+			}
+			return nil, fmt.Errorf("waiting for task %s %s aborted: %w", kind, taskId, bc.ctx().Err())
+		case <-time.After(taskPollPolicy.backoff(attempt)):
+		}
+	}
+}
+
+// TaskResult returns the result of the finished task.
+func (bc *BIGIPContext) TaskResult(kind, taskId string) (map[string]interface{}, error) {
+	jresp, err := bc.requestJSON("GET", taskUri(kind, taskId)+"/result", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get result of task %s %s: %w", kind, taskId, err)
+	}
+	return jresp, nil
+}
+
+// CancelTask requests to cancel the running task.
+func (bc *BIGIPContext) CancelTask(kind, taskId string) error {
+	_, err := bc.requestJSON("PUT", taskUri(kind, taskId), map[string]interface{}{"_taskState": "CANCEL_REQUESTED"})
+	return err
+}
+
+// DeleteTask deletes the task, which is kept on BIG-IP after it's finished.
+func (bc *BIGIPContext) DeleteTask(kind, taskId string) error {
+	_, err := bc.requestJSON("DELETE", taskUri(kind, taskId), nil)
+	return err
+}
+
+func taskUri(kind, taskId string) string {
+	return "/mgmt/tm/task/" + kind + "/" + taskId
+}
@@ -0,0 +1,78 @@
+package f5_bigip
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBIGIPContext_SaveSysConfig_async(t *testing.T) {
+	var created map[string]interface{}
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/mgmt/tm/task/sys/config":
+			json.NewDecoder(r.Body).Decode(&created)
+			fmt.Fprint(w, `{"_taskId":2002}`)
+		case r.URL.Path == "/mgmt/tm/task/sys/config/2002/result":
+			fmt.Fprint(w, `{}`)
+		case r.URL.Path == "/mgmt/tm/task/sys/config/2002":
+			fmt.Fprint(w, `{"_taskState":"COMPLETED"}`)
+		default:
+			w.WriteHeader(404)
+		}
+	}, WithAsyncTasks())
+	defer done()
+
+	if err := bc.SaveSysConfig([]string{"p1", "p2"}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"command": "save",
+		"options": []interface{}{map[string]interface{}{"partitions": "{ p1 p2 }"}},
+	}
+	if !reflect.DeepEqual(created, want) {
+		t.Errorf("task body = %v, want %v", created, want)
+	}
+}
+
+func TestBIGIPContext_RunTask_cancelled(t *testing.T) {
+	requests := []string{}
+	var mutex sync.Mutex
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch {
+		case r.Method == "POST":
+			fmt.Fprint(w, `{"_taskId":"3003"}`)
+		case r.Method == "GET":
+			fmt.Fprint(w, `{"_taskState":"STARTED"}`)
+		default:
+			body := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			requests = append(requests, fmt.Sprintf("%s %v", r.Method, body["_taskState"]))
+			fmt.Fprint(w, `{}`)
+		}
+	})
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 500*time.Millisecond)
+	defer cancel()
+	bc.Context = ctx
+
+	_, err := bc.RunTask("cli/script", map[string]interface{}{"command": "run", "name": "/Common/s1"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	want := []string{"PUT VALIDATING", "PUT CANCEL_REQUESTED", "DELETE <nil>"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
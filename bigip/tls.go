@@ -0,0 +1,136 @@
+package f5_bigip
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// WithCABundle verifies the certificate of BIG-IP with the CAs in the given PEM file.
+func WithCABundle(file string) Option {
+	return func(o *bigipOptions) error {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read ca bundle %s: %s", file, err.Error())
+		}
+		return WithCABundlePEM(pem)(o)
+	}
+}
+
+// WithCABundlePEM verifies the certificate of BIG-IP with the CAs in the given PEM bytes.
+func WithCABundlePEM(pem []byte) Option {
+	return func(o *bigipOptions) error {
+		if o.rootCAs == nil {
+			o.rootCAs = x509.NewCertPool()
+		}
+		if !o.rootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found from the ca bundle")
+		}
+		return nil
+	}
+}
+
+// WithClientCertificate presents the certificate in the given PEM files to BIG-IP for mTLS.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(o *bigipOptions) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate %s: %s", certFile, err.Error())
+		}
+		o.certificates = append(o.certificates, cert)
+		return nil
+	}
+}
+
+// WithClientCertificatePEM presents the given PEM certificate and key to BIG-IP for mTLS.
+func WithClientCertificatePEM(certPEM, keyPEM []byte) Option {
+	return func(o *bigipOptions) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("failed to parse client certificate: %s", err.Error())
+		}
+		o.certificates = append(o.certificates, cert)
+		return nil
+	}
+}
+
+// WithServerName overrides the server name used for SNI and hostname verification,
+// it's useful when BIG-IP is accessed by IP address.
+func WithServerName(name string) Option {
+	return func(o *bigipOptions) error {
+		o.serverName = name
+		return nil
+	}
+}
+
+// WithFingerprints pins the certificate of BIG-IP to the given SHA-256 fingerprints,
+// in hex with or without colons, i.e. "AB:CD:..." as 'openssl x509 -fingerprint -sha256' shows.
+// Without CA bundles, the pinning replaces the chain verification, which fits self-signed
+// management certificates. With CA bundles, both of them are required to pass.
+func WithFingerprints(fingerprints ...string) Option {
+	return func(o *bigipOptions) error {
+		for _, fp := range fingerprints {
+			b, err := hex.DecodeString(strings.ReplaceAll(fp, ":", ""))
+			if err != nil || len(b) != sha256.Size {
+				return fmt.Errorf("invalid sha256 fingerprint: %s", fp)
+			}
+			o.fingerprints = append(o.fingerprints, b)
+		}
+		return nil
+	}
+}
+
+// buildTLSConfig merges the TLS related options into one tls.Config.
+// The certificate is not verified unless CA bundles or fingerprints are given.
+func buildTLSConfig(o *bigipOptions) *tls.Config {
+	config := o.tlsConfig
+	if config == nil {
+		config = &tls.Config{InsecureSkipVerify: true}
+	}
+	if o.rootCAs != nil {
+		config.RootCAs = o.rootCAs
+		config.InsecureSkipVerify = false
+	}
+	if len(o.certificates) > 0 {
+		config.Certificates = append(config.Certificates, o.certificates...)
+	}
+	if o.serverName != "" {
+		config.ServerName = o.serverName
+	}
+	if len(o.fingerprints) > 0 {
+		if o.rootCAs == nil {
+			config.InsecureSkipVerify = true
+		}
+		config.VerifyConnection = verifyFingerprints(o.fingerprints)
+	}
+	return config
+}
+
+// tlsVerified tells if the certificate of BIG-IP is verified by the chain or the pinned fingerprints.
+func tlsVerified(config *tls.Config) bool {
+	return !config.InsecureSkipVerify || config.VerifyConnection != nil
+}
+
+func verifyFingerprints(fingerprints [][]byte) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return &utils.CertificateError{Err: fmt.Errorf("no certificate presented")}
+		}
+		sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+		for _, fp := range fingerprints {
+			if bytes.Equal(sum[:], fp) {
+				return nil
+			}
+		}
+		return &utils.CertificateError{
+			Err: fmt.Errorf("sha256 fingerprint %s is not pinned", hex.EncodeToString(sum[:])),
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package f5_bigip
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+func Test_buildTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	sum := sha256.Sum256(server.Certificate().Raw)
+	pinned := hex.EncodeToString(sum[:])
+	other := hex.EncodeToString(make([]byte, sha256.Size))
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		certErr  bool
+		verified bool
+	}{
+		{name: "default skips verification", opts: []Option{}, certErr: false, verified: false},
+		{name: "unknown authority", opts: []Option{WithCABundlePEM(testCAPEM(t))}, certErr: true, verified: true},
+		{name: "pinned fingerprint", opts: []Option{WithFingerprints(pinned)}, certErr: false, verified: true},
+		{name: "mismatched fingerprint", opts: []Option{WithFingerprints(other)}, certErr: true, verified: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := bigipOptions{}
+			for _, opt := range tt.opts {
+				if err := opt(&o); err != nil {
+					t.Fatalf("option failed: %s", err)
+				}
+			}
+			config := buildTLSConfig(&o)
+			if got := tlsVerified(config); got != tt.verified {
+				t.Errorf("verified = %v, want %v", got, tt.verified)
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+			_, _, err := utils.HttpRequest(client, server.URL, "GET", "", map[string]string{})
+			var certErr *utils.CertificateError
+			if got := errors.As(err, &certErr); got != tt.certErr {
+				t.Errorf("certificate error = %v, want %v: %v", got, tt.certErr, err)
+			}
+			if utils.NeedRetry(err) {
+				t.Errorf("certificate error should not be retried: %v", err)
+			}
+		})
+	}
+}
+
+// testCAPEM generates a self-signed CA which signs nothing of the test server.
+func testCAPEM(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "unittest-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -0,0 +1,71 @@
+package f5_bigip
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// TransCommands returns the commands in the transaction sorted by evalOrder,
+// each has the fields like "evalOrder", "method", "uri" and "body".
+func (bc *BIGIPContext) TransCommands(transId float64) ([]map[string]interface{}, error) {
+	jresp, err := bc.requestJSON("GET", fmt.Sprintf("/mgmt/tm/transaction/%.f/commands", transId), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retriving commands of transaction %.f %w", transId, err)
+	}
+	commands := []map[string]interface{}{}
+	items, _ := jresp["items"].([]interface{})
+	for _, item := range items {
+		if command, ok := item.(map[string]interface{}); ok {
+			commands = append(commands, command)
+		}
+	}
+	sort.SliceStable(commands, func(i, j int) bool { return evalOrder(commands[i]) < evalOrder(commands[j]) })
+	return commands, nil
+}
+
+// transactionError wraps err of committing or validating the transaction into *TransactionError.
+// The commands of the transaction are matched to the RestRequests sent, cmds, by evalOrder,
+// and the failed one is the command whose resource is named in the iControl message.
+func (bc *BIGIPContext) transactionError(transId float64, cmds []RestRequest, err error) error {
+	slog := utils.LogFromContext(bc.Context)
+
+	terr := &TransactionError{TransId: transId, Message: err.Error(), Err: err}
+	var berr *BigipError
+	if errors.As(err, &berr) && berr.Message != "" {
+		terr.Message = berr.Message
+	}
+
+	commands, cerr := bc.TransCommands(transId)
+	if cerr != nil {
+		slog.Warnf("failed to find the failed command: %s", cerr.Error())
+		return terr
+	}
+	matched := ""
+	for _, command := range commands {
+		order := evalOrder(command)
+		if order < 1 || order > len(cmds) {
+			continue
+		}
+		r := cmds[order-1]
+		if method, _ := command["method"].(string); method != r.Method || r.ResName == "" {
+			continue
+		}
+		fullPath := "/" + uriname(r.Partition, r.Subfolder, r.ResName)
+		// the longest path wins, i.e. /p/f/vs1 rather than /p/f.
+		if len(fullPath) > len(matched) && regexp.MustCompile(regexp.QuoteMeta(fullPath)+`([^\w.:-]|$)`).MatchString(terr.Message) {
+			matched = fullPath
+			terr.EvalOrder = order
+			terr.Request = &cmds[order-1]
+		}
+	}
+	return terr
+}
+
+func evalOrder(command map[string]interface{}) int {
+	order, _ := command["evalOrder"].(float64)
+	return int(order)
+}
@@ -0,0 +1,178 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// GetTSDeclaration returns the current Telemetry Streaming declaration, with the defaults filled by TS.
+func (bc *BIGIPContext) GetTSDeclaration() (map[string]interface{}, error) {
+	jresp, err := bc.requestJSON("GET", tsUriPrefix+"/declare", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retriving ts declaration %w", err)
+	}
+	decl, _ := jresp["declaration"].(map[string]interface{})
+	if decl == nil {
+		decl = map[string]interface{}{}
+	}
+	return decl, nil
+}
+
+// ValidateTS validates the Telemetry Streaming declaration without applying it.
+func (bc *BIGIPContext) ValidateTS(declaration map[string]interface{}) error {
+	if declaration["class"] != "Telemetry" {
+		return fmt.Errorf("not support, class %v", declaration["class"])
+	}
+	if _, err := bc.requestJSON("POST", tsUriPrefix+"/validateDeclaration", declaration); err != nil {
+		return fmt.Errorf("invalid ts declaration: %w", err)
+	}
+	return nil
+}
+
+// DeployTS posts the Telemetry Streaming declaration, the applied declaration is returned.
+// The declaration {"class": "Telemetry"} removes all the TS configurations.
+func (bc *BIGIPContext) DeployTS(declaration map[string]interface{}) (map[string]interface{}, error) {
+	defer utils.TimeItToPrometheus()()
+
+	if declaration["class"] != "Telemetry" {
+		return nil, fmt.Errorf("not support, class %v", declaration["class"])
+	}
+	jresp, err := bc.requestJSON("POST", tsUriPrefix+"/declare", declaration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post ts declaration: %w", err)
+	}
+	decl, _ := jresp["declaration"].(map[string]interface{})
+	return decl, nil
+}
+
+// TSDeclarationChanged tells if the declaration differs from the current one on BIG-IP.
+// TS returns the secrets encrypted, which can't be compared with the plain text ones, so a declaration with
+// plain text secrets, like {"passphrase": {"cipherText": "..."}}, is always changed, for the secrets to be rotated.
+// The encrypted ones, starting with "$M$" as returned by TS, are not compared.
+func (bc *BIGIPContext) TSDeclarationChanged(declaration map[string]interface{}) (bool, error) {
+	current, err := bc.GetTSDeclaration()
+	if err != nil {
+		return false, err
+	}
+	// numbers in the declaration built by code are not float64 as parsed.
+	desired := map[string]interface{}{}
+	if err := remarshal(declaration, &desired); err != nil {
+		return false, err
+	}
+	return tsDeclarationChanged(current, desired), nil
+}
+
+// tsDeclarationChanged compares the declarations, the current one has the defaults filled by TS,
+// so it's unchanged if it contains everything desired and no other objects.
+// The secrets are returned encrypted by TS, so they're only compared by presence, see maskSecrets,
+// unless there are plain text ones desired.
+func tsDeclarationChanged(current, desired map[string]interface{}) bool {
+	if hasPlainSecrets(desired) {
+		return true
+	}
+	current = maskSecrets(current).(map[string]interface{})
+	desired = maskSecrets(desired).(map[string]interface{})
+	for k, v := range current {
+		if m, ok := v.(map[string]interface{}); ok && m["class"] != nil {
+			if _, f := desired[k]; !f {
+				return true
+			}
+		}
+	}
+	for k, v := range desired {
+		if k == "schemaVersion" {
+			continue
+		}
+		if !jsonContains(current[k], v) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskSecrets returns a copy of the parsed JSON v, with the passphrases and the objects of cipherText
+// replaced by a mask.
+func maskSecrets(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		if _, f := vv["cipherText"]; f {
+			return "******"
+		}
+		masked := map[string]interface{}{}
+		for k, i := range vv {
+			if k == "passphrase" {
+				masked[k] = "******"
+			} else {
+				masked[k] = maskSecrets(i)
+			}
+		}
+		return masked
+	case []interface{}:
+		masked := []interface{}{}
+		for _, i := range vv {
+			masked = append(masked, maskSecrets(i))
+		}
+		return masked
+	default:
+		return v
+	}
+}
+
+// hasPlainSecrets tells if the parsed JSON v has a secret not encrypted by TS.
+func hasPlainSecrets(v interface{}) bool {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		if c, f := vv["cipherText"]; f {
+			s, _ := c.(string)
+			return !strings.HasPrefix(s, "$M$")
+		}
+		for k, i := range vv {
+			if s, ok := i.(string); ok && k == "passphrase" && !strings.HasPrefix(s, "$M$") {
+				return true
+			}
+			if hasPlainSecrets(i) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, i := range vv {
+			if hasPlainSecrets(i) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonContains tells if the parsed JSON a has all the fields of b with the same values.
+func jsonContains(a, b interface{}) bool {
+	switch bv := b.(type) {
+	case map[string]interface{}:
+		av, ok := a.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range bv {
+			if !jsonContains(av[k], v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		av, ok := a.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range bv {
+			if !jsonContains(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
@@ -0,0 +1,89 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_tsDeclarationChanged(t *testing.T) {
+	current := map[string]interface{}{}
+	json.Unmarshal([]byte(`{
+		"class": "Telemetry",
+		"schemaVersion": "1.30.0",
+		"controls": {"class": "Controls", "logLevel": "info", "debug": false},
+		"My_Consumer": {
+			"class": "Telemetry_Consumer",
+			"type": "Generic_HTTP",
+			"host": "192.0.2.1",
+			"port": 443,
+			"protocol": "https",
+			"headers": [{"name": "content-type", "value": "application/json"}],
+			"passphrase": {"cipherText": "$M$Zq$encrypted==", "class": "Secret", "protected": "SecureVault"},
+			"privateKey": {"cipherText": "$M$Ab$encrypted==", "protected": "SecureVault"}
+		}
+	}`), &current)
+
+	tests := []struct {
+		name    string
+		desired string
+		changed bool
+	}{
+		{
+			name: "same with defaults omitted",
+			desired: `{"class": "Telemetry", "schemaVersion": "1.20.0",
+				"controls": {"class": "Controls"},
+				"My_Consumer": {"class": "Telemetry_Consumer", "type": "Generic_HTTP", "host": "192.0.2.1", "port": 443,
+					"headers": [{"name": "content-type", "value": "application/json"}]}}`,
+			changed: false,
+		},
+		{
+			name: "secrets in plain text",
+			desired: `{"class": "Telemetry", "controls": {"class": "Controls"},
+				"My_Consumer": {"class": "Telemetry_Consumer", "type": "Generic_HTTP", "host": "192.0.2.1",
+					"passphrase": {"cipherText": "secret"}, "privateKey": {"cipherText": "key"}}}`,
+			changed: true,
+		},
+		{
+			name: "secrets encrypted",
+			desired: `{"class": "Telemetry", "controls": {"class": "Controls"},
+				"My_Consumer": {"class": "Telemetry_Consumer", "type": "Generic_HTTP", "host": "192.0.2.1",
+					"passphrase": {"cipherText": "$M$Zq$reencrypted=="}, "privateKey": {"cipherText": "$M$Ab$encrypted=="}}}`,
+			changed: false,
+		},
+		{
+			name: "secret added",
+			desired: `{"class": "Telemetry", "controls": {"class": "Controls"},
+				"My_Consumer": {"class": "Telemetry_Consumer", "type": "Generic_HTTP", "host": "192.0.2.1",
+					"clientCertificate": {"cipherText": "$M$Cd$encrypted=="}}}`,
+			changed: true,
+		},
+		{
+			name: "value changed",
+			desired: `{"class": "Telemetry", "controls": {"class": "Controls"},
+				"My_Consumer": {"class": "Telemetry_Consumer", "type": "Generic_HTTP", "host": "192.0.2.2"}}`,
+			changed: true,
+		},
+		{
+			name:    "object removed",
+			desired: `{"class": "Telemetry", "controls": {"class": "Controls"}}`,
+			changed: true,
+		},
+		{
+			name: "array changed",
+			desired: `{"class": "Telemetry", "controls": {"class": "Controls"},
+				"My_Consumer": {"class": "Telemetry_Consumer", "headers": []}}`,
+			changed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(tt.desired), &desired); err != nil {
+				t.Fatal(err)
+			}
+			if got := tsDeclarationChanged(current, desired); got != tt.changed {
+				t.Errorf("tsDeclarationChanged() = %v, want %v", got, tt.changed)
+			}
+		})
+	}
+}
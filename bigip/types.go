@@ -2,7 +2,11 @@ package f5_bigip
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"net/http"
+	"sync"
+	"time"
 )
 
 type RestRequest struct {
@@ -11,11 +15,14 @@ type RestRequest struct {
 	Subfolder string
 	Kind      string
 
-	Method     string
-	ResUri     string
-	Headers    map[string]interface{}
-	Body       interface{}
-	WithTrans  bool
+	Method    string
+	ResUri    string
+	Headers   map[string]interface{}
+	Body      interface{}
+	WithTrans bool
+	// ScheduleIt defers the request out of the transaction, to be run by the syncer of BIGIP:
+	// "after-commit", a duration like "30s" after the commit, "@every <duration>", a cron spec like "0 3 * * *"
+	// in the local time, or "never".
 	ScheduleIt string
 }
 
@@ -24,6 +31,99 @@ type BIGIP struct {
 	URL           string
 	Authorization string
 	client        *http.Client
+	token         *tokenAuth
+	version       *versionCache
+	retry         RetryPolicy
+	asyncTasks    bool
+	minimalPatch  bool
+	syncer        *resSyncer
+	// maxTransCommands limits the commands of a transaction, 0 means no limit.
+	maxTransCommands int
+}
+
+// RetryPolicy controls how the retryable failures, see utils.NeedRetry, are retried.
+// The zero value means no retry.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt, it's doubled for each later one.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts.
+	MaxBackoff time.Duration
+	// Deadline limits the overall time spent on all attempts, 0 means no limit.
+	Deadline time.Duration
+}
+
+// Option customizes the BIGIP created by NewBIGIP.
+type Option func(*bigipOptions) error
+
+type bigipOptions struct {
+	timeout       time.Duration
+	tlsConfig     *tls.Config
+	rootCAs       *x509.CertPool
+	certificates  []tls.Certificate
+	serverName    string
+	fingerprints  [][]byte
+	authorization string
+	token         *tokenAuth
+	lazyVersion   bool
+	partitions    []string
+	retry         RetryPolicy
+	asyncTasks    bool
+	minimalPatch  bool
+	// maxTransCommands limits the commands of a transaction, 0 means no limit.
+	maxTransCommands int
+}
+
+// versionCache keeps the version discovered lazily, shared by all copies of BIGIP.
+type versionCache struct {
+	version string
+	mutex   sync.Mutex
+}
+
+// resSyncer runs the rest requests with ScheduleIt, shared by all copies of BIGIP.
+type resSyncer struct {
+	mutex   sync.Mutex
+	results map[string]*ScheduledResult
+	// stops ends the periodic runs of "@every" and cron requests, by the key of the request.
+	stops map[string]chan struct{}
+	// finished keeps the results of the finished one-shot requests in order, to drop the oldest ones.
+	finished []*ScheduledResult
+}
+
+// cronSpec is a parsed cron schedule, with the matched values of each field as bits.
+type cronSpec struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	// anyDay and anyWeekday tell if the field starts with "*".
+	anyDay     bool
+	anyWeekday bool
+}
+
+// ScheduledResult reports the runs of a rest request with ScheduleIt.
+type ScheduledResult struct {
+	Request RestRequest
+	// Runs is the number of finished runs, each with retries.
+	Runs    int
+	LastRun time.Time
+	// Err is the error of the last run, or of an invalid ScheduleIt, nil if it succeeded.
+	Err error
+	// Skipped is true if the request is scheduled "never".
+	Skipped bool
+}
+
+// tokenAuth keeps the X-F5-Auth-Token obtained from /mgmt/shared/authn/login.
+// It is referred by pointer so that all copies of BIGIP share the same token.
+type tokenAuth struct {
+	username          string
+	password          string
+	loginProviderName string
+	token             string
+	expiration        time.Time
+	mutex             sync.Mutex
 }
 
 type BIGIPContext struct {
@@ -38,3 +138,275 @@ type BIGIPVersion struct {
 	Title   string
 	Version string
 }
+
+// BigipError is the error of a failed iControl call, use errors.As to get it from the returned errors.
+// The fields Code, Message, APIError and ErrorStack are parsed from the iControl error response.
+type BigipError struct {
+	StatusCode int
+	Code       int           `json:"code"`
+	Message    string        `json:"message"`
+	APIError   int           `json:"apiError"`
+	ErrorStack []interface{} `json:"errorStack"`
+	Method     string
+	URI        string
+	Body       string
+	Retryable  bool
+}
+
+// ListQuery builds the OData-like query parameters of iControl collection listing,
+// i.e. $filter, $select, $top, $skip and expandSubcollections.
+type ListQuery struct {
+	filters []string
+	selects []string
+	top     int
+	skip    int
+	expand  bool
+}
+
+// ListIterator iterates over the items of a collection page by page, see BIGIPContext.Iterate.
+type ListIterator struct {
+	bc    *BIGIPContext
+	kind  string
+	next  string
+	items []interface{}
+	item  map[string]interface{}
+	err   error
+}
+
+// UploadOptions customizes the chunked uploading, the zero value works with defaults.
+type UploadOptions struct {
+	// ChunkSize is the size of each ranged request, DefaultUploadChunkSize if not set.
+	ChunkSize int64
+	// Retries is the number of attempts for each chunk, DefaultUploadChunkRetries if not set.
+	Retries int
+	// Progress is called after each chunk is uploaded with the uploaded and the total size.
+	Progress func(uploaded, total int64)
+	// Verify compares the sha256 checksum of the uploaded file with the local one.
+	Verify bool
+}
+
+// DownloadOptions customizes the chunked downloading, the zero value works with defaults.
+type DownloadOptions struct {
+	// ChunkSize is the size of each ranged request, DefaultDownloadChunkSize if not set.
+	ChunkSize int64
+	// Retries is the number of attempts for each chunk, DefaultDownloadChunkRetries if not set.
+	Retries int
+	// Offset is where to start downloading, used to resume an interrupted downloading
+	// with the size returned by the previous call.
+	Offset int64
+	// MaxSize refuses files larger than it before anything is written, no limit if not set.
+	MaxSize int64
+	// Progress is called after each chunk is written with the downloaded and the total size.
+	Progress func(downloaded, total int64)
+}
+
+// UCSSaveOptions customizes the UCS archive to create, the zero value works with defaults.
+type UCSSaveOptions struct {
+	// Passphrase encrypts the archive if set.
+	Passphrase string
+	// NoPrivateKey excludes the SSL private keys from the archive.
+	NoPrivateKey bool
+}
+
+// UCSLoadOptions customizes restoring from a UCS archive, the zero value works with defaults.
+type UCSLoadOptions struct {
+	// Passphrase decrypts the archive if it's encrypted.
+	Passphrase string
+	// NoLicense keeps the license of the device instead of the one in the archive.
+	NoLicense bool
+	// ResetTrust resets the device trust, used when restoring to another device.
+	ResetTrust bool
+	// NoPlatformCheck skips the platform validation of the archive.
+	NoPlatformCheck bool
+}
+
+// UCSArchive is a UCS archive in /var/local/ucs/ on BIG-IP.
+type UCSArchive struct {
+	Name      string
+	FileName  string
+	Size      int64
+	Created   string
+	Version   string
+	Encrypted bool
+}
+
+// Stats is the flattened nestedStats of a resource, keyed by the entry names like "clientside.curConns".
+// The entries of deeper nestedStats are keyed with their parents' names joined by dots.
+type Stats struct {
+	// Counters are the entries with numeric values.
+	Counters map[string]uint64
+	// Descriptions are the entries with string descriptions, like "status.availabilityState".
+	Descriptions map[string]string
+}
+
+// VirtualStats is the statistics of a ltm virtual.
+type VirtualStats struct {
+	FullPath          string
+	AvailabilityState string
+	EnabledState      string
+	StatusReason      string
+	BitsIn            uint64
+	BitsOut           uint64
+	PktsIn            uint64
+	PktsOut           uint64
+	CurConns          uint64
+	MaxConns          uint64
+	TotConns          uint64
+}
+
+// PoolStats is the statistics of a ltm pool.
+type PoolStats struct {
+	FullPath          string
+	AvailabilityState string
+	EnabledState      string
+	StatusReason      string
+	ActiveMemberCnt   uint64
+	BitsIn            uint64
+	BitsOut           uint64
+	PktsIn            uint64
+	PktsOut           uint64
+	CurConns          uint64
+	MaxConns          uint64
+	TotConns          uint64
+	CurSessions       uint64
+}
+
+// PoolMemberStats is the statistics of a member of ltm pool.
+type PoolMemberStats struct {
+	FullPath          string
+	Address           string
+	Port              uint64
+	NodeName          string
+	AvailabilityState string
+	EnabledState      string
+	SessionStatus     string
+	StatusReason      string
+	BitsIn            uint64
+	BitsOut           uint64
+	PktsIn            uint64
+	PktsOut           uint64
+	CurConns          uint64
+	MaxConns          uint64
+	TotConns          uint64
+	CurSessions       uint64
+}
+
+// PoolMember is a member of ltm pool, with the fields of the session and monitor states.
+type PoolMember struct {
+	Name            string `json:"name"`
+	Partition       string `json:"partition"`
+	FullPath        string `json:"fullPath"`
+	Address         string `json:"address"`
+	Description     string `json:"description,omitempty"`
+	ConnectionLimit int    `json:"connectionLimit"`
+	PriorityGroup   int    `json:"priorityGroup"`
+	Ratio           int    `json:"ratio"`
+	// Session is "user-enabled", "user-disabled", or "monitor-enabled" as reported by BIG-IP.
+	Session string `json:"session"`
+	// State is "user-up", "user-down", or the monitor states like "up", "down", "unchecked".
+	State string `json:"state"`
+}
+
+// Command is a tmsh or bash command line built from separately escaped arguments,
+// see NewTmshCommand and NewBashCommand. It's run on BIG-IP by BIGIPContext.Run.
+type Command struct {
+	tmsh bool
+	// words are escaped already.
+	words []string
+}
+
+// CommandResult is the outcome of a command run on BIG-IP.
+type CommandResult struct {
+	ExitStatus int
+	Stdout     string
+	Stderr     string
+}
+
+// AS3Info is the version information of the AS3 package installed on BIG-IP.
+type AS3Info struct {
+	Version       string `json:"version"`
+	Release       string `json:"release"`
+	SchemaCurrent string `json:"schemaCurrent"`
+	SchemaMinimum string `json:"schemaMinimum"`
+}
+
+// AS3Result is the result of deploying a declaration to a tenant.
+type AS3Result struct {
+	Code      int      `json:"code"`
+	Message   string   `json:"message"`
+	Tenant    string   `json:"tenant"`
+	Host      string   `json:"host"`
+	RunTime   int      `json:"runTime"`
+	LineCount int      `json:"lineCount"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// DOResult is the result of a Declarative Onboarding task.
+type DOResult struct {
+	Class   string   `json:"class"`
+	Code    int      `json:"code"`
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// TransactionError is the failure of committing or validating a transaction,
+// with the command that caused it if it can be told from the iControl message.
+// BIG-IP doesn't report the evalOrder of the failed command, so it's a best guess:
+// the command whose resource path is named in the message, the longest one if several are.
+type TransactionError struct {
+	TransId float64
+	// EvalOrder is the order of the failed command in the transaction, 0 if unknown.
+	EvalOrder int
+	// Request is the RestRequest of the failed command, nil if unknown.
+	Request *RestRequest
+	// Message is the iControl message of the failure.
+	Message string
+	Err     error
+}
+
+// BatchResult is the outcome of a batch of the requests split by the limit of WithMaxTransCommands.
+type BatchResult struct {
+	Requests []RestRequest
+	// Done tells the batch is committed.
+	Done bool
+	// Err is the failure of the batch. The batches after a failed one are not tried, with neither Done nor Err.
+	Err error
+}
+
+// BatchError is returned by DoRestRequests when a batch fails, the results tell which batches are committed.
+type BatchError struct {
+	Results []BatchResult
+}
+
+// Plan is the human-readable form of the rest requests generated by GenRestRequests,
+// grouped into the ones to create, update and delete, sorted by partition, folder, kind and name.
+type Plan struct {
+	Create []PlanChange `json:"create"`
+	Update []PlanChange `json:"update"`
+	Delete []PlanChange `json:"delete"`
+	// Other is the requests out of transaction, like uploading files and the scheduled ones.
+	Other []PlanChange `json:"other"`
+}
+
+// PlanChange is a resource to be changed in Plan.
+type PlanChange struct {
+	Partition string `json:"partition"`
+	Folder    string `json:"folder,omitempty"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Method    string `json:"method"`
+	// Schedule is the ScheduleIt of the request.
+	Schedule string `json:"schedule,omitempty"`
+	// Body is the body of the resource to create.
+	Body interface{} `json:"body,omitempty"`
+	// Diff is the fields to update, compared with the existing resource.
+	Diff []FieldDiff `json:"diff,omitempty"`
+}
+
+// FieldDiff is a field to update, Old is nil if the field doesn't exist.
+type FieldDiff struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
@@ -0,0 +1,119 @@
+package f5_bigip
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// SaveUCS creates the UCS archive /var/local/ucs/<name> on BIG-IP and waits until it's done. opts can be nil.
+func (bc *BIGIPContext) SaveUCS(name string, opts *UCSSaveOptions) error {
+	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(bc.Context)
+
+	options := []map[string]string{}
+	if opts != nil {
+		if opts.Passphrase != "" {
+			options = append(options, map[string]string{"passphrase": tmshQuote(opts.Passphrase)})
+		}
+		if opts.NoPrivateKey {
+			options = append(options, map[string]string{"no-private-key": ""})
+		}
+	}
+	slog.Infof("saving ucs %s on %s", name, bc.URL)
+	return bc.runUCSTask("save", name, options)
+}
+
+// LoadUCS restores BIG-IP from the UCS archive /var/local/ucs/<name> and waits until it's done. opts can be nil.
+// The archive can be uploaded by UploadUCS beforehand.
+func (bc *BIGIPContext) LoadUCS(name string, opts *UCSLoadOptions) error {
+	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(bc.Context)
+
+	options := []map[string]string{}
+	if opts != nil {
+		if opts.Passphrase != "" {
+			options = append(options, map[string]string{"passphrase": tmshQuote(opts.Passphrase)})
+		}
+		if opts.NoLicense {
+			options = append(options, map[string]string{"no-license": ""})
+		}
+		if opts.ResetTrust {
+			options = append(options, map[string]string{"reset-trust": ""})
+		}
+		if opts.NoPlatformCheck {
+			options = append(options, map[string]string{"no-platform-check": ""})
+		}
+	}
+	slog.Infof("loading ucs %s on %s", name, bc.URL)
+	return bc.runUCSTask("load", name, options)
+}
+
+// ListUCS returns the UCS archives on BIG-IP.
+func (bc *BIGIPContext) ListUCS() ([]UCSArchive, error) {
+	defer utils.TimeItToPrometheus()()
+
+	archives := []UCSArchive{}
+	jresp, err := bc.requestJSON("GET", "/mgmt/tm/sys/ucs", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retriving ucs archives %w", err)
+	}
+	items, _ := jresp["items"].([]interface{})
+	for _, item := range items {
+		raw, _ := item.(map[string]interface{})["apiRawValues"].(map[string]interface{})
+		if raw == nil {
+			continue
+		}
+		archive := UCSArchive{}
+		archive.FileName, _ = raw["filename"].(string)
+		archive.Name = path.Base(archive.FileName)
+		archive.Created, _ = raw["file_created_date"].(string)
+		archive.Version, _ = raw["version"].(string)
+		encrypted, _ := raw["encrypted"].(string)
+		archive.Encrypted = encrypted == "yes"
+		// file_size is like "284806 (in bytes)"
+		if size, ok := raw["file_size"].(string); ok {
+			fields := strings.Fields(size)
+			if len(fields) > 0 {
+				archive.Size, _ = strconv.ParseInt(fields[0], 10, 64)
+			}
+		}
+		archives = append(archives, archive)
+	}
+	return archives, nil
+}
+
+// DeleteUCS deletes the UCS archive /var/local/ucs/<name> on BIG-IP.
+func (bc *BIGIPContext) DeleteUCS(name string) error {
+	defer utils.TimeItToPrometheus()()
+
+	_, err := bc.requestJSON("DELETE", "/mgmt/tm/sys/ucs/"+name, nil)
+	return err
+}
+
+// UploadUCS uploads size bytes from r as the UCS archive /var/local/ucs/<name> on BIG-IP, in ranged chunks.
+// The archive is downloaded by DownloadUCS, and restored by LoadUCS. opts can be nil.
+func (bc *BIGIPContext) UploadUCS(name string, r io.Reader, size int64, opts *UploadOptions) (string, error) {
+	return bc.upload(ucsUploadUriPrefix+name, r, size, opts)
+}
+
+// runUCSTask runs the ucs command as a task,
+// saving and loading UCS archives take longer than the timeout of a single iControl call.
+// The options are in the same form as the ones of saveSysConfigTask, with the values quoted for tmsh.
+func (bc *BIGIPContext) runUCSTask(command, name string, options []map[string]string) error {
+	body := map[string]interface{}{
+		"command": command,
+		"name":    name,
+	}
+	if len(options) > 0 {
+		body["options"] = options
+	}
+	if _, err := bc.RunTask("sys/ucs", body); err != nil {
+		return fmt.Errorf("failed to %s ucs %s: %w", command, name, err)
+	}
+	return nil
+}
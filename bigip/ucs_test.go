@@ -0,0 +1,84 @@
+package f5_bigip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBIGIPContext_SaveUCS(t *testing.T) {
+	polled := 0
+	deleted := false
+	var created map[string]interface{}
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/mgmt/tm/task/sys/ucs":
+			json.NewDecoder(r.Body).Decode(&created)
+			fmt.Fprint(w, `{"_taskId":"1001","_taskState":"CREATED"}`)
+		case r.Method == "PUT" && r.URL.Path == "/mgmt/tm/task/sys/ucs/1001":
+			fmt.Fprint(w, `{"_taskId":"1001","_taskState":"VALIDATING"}`)
+		case r.Method == "GET" && r.URL.Path == "/mgmt/tm/task/sys/ucs/1001":
+			polled++
+			state := "STARTED"
+			if polled == 3 {
+				state = "COMPLETED"
+			}
+			fmt.Fprintf(w, `{"_taskId":"1001","_taskState":"%s"}`, state)
+		case r.Method == "GET" && r.URL.Path == "/mgmt/tm/task/sys/ucs/1001/result":
+			fmt.Fprint(w, `{"_taskId":"1001","_taskState":"COMPLETED"}`)
+		case r.Method == "DELETE" && r.URL.Path == "/mgmt/tm/task/sys/ucs/1001":
+			deleted = true
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(404)
+		}
+	})
+	defer done()
+
+	if err := bc.SaveUCS("backup.ucs", &UCSSaveOptions{Passphrase: `my "secret" no-private-key`, NoPrivateKey: true}); err != nil {
+		t.Fatal(err)
+	}
+	if polled != 3 {
+		t.Errorf("polled %d times, want 3", polled)
+	}
+	if !deleted {
+		t.Errorf("finished task was not deleted")
+	}
+	want := map[string]interface{}{
+		"command": "save",
+		"name":    "backup.ucs",
+		"options": []interface{}{
+			map[string]interface{}{"passphrase": `"my \"secret\" no-private-key"`},
+			map[string]interface{}{"no-private-key": ""},
+		},
+	}
+	if !reflect.DeepEqual(created, want) {
+		t.Errorf("task body = %v, want %v", created, want)
+	}
+}
+
+func TestBIGIPContext_ListUCS(t *testing.T) {
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items":[{"apiRawValues":{"encrypted":"yes","file_created_date":"2022-10-17T08:00:00Z",`+
+			`"file_size":"284806 (in bytes)","filename":"/var/local/ucs/backup.ucs","version":"16.1.0"}}]}`)
+	})
+	defer done()
+
+	archives, err := bc.ListUCS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []UCSArchive{{
+		Name:      "backup.ucs",
+		FileName:  "/var/local/ucs/backup.ucs",
+		Size:      284806,
+		Created:   "2022-10-17T08:00:00Z",
+		Version:   "16.1.0",
+		Encrypted: true,
+	}}
+	if !reflect.DeepEqual(archives, want) {
+		t.Errorf("ListUCS() = %v, want %v", archives, want)
+	}
+}
@@ -0,0 +1,126 @@
+package f5_bigip
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// UploadReader uploads size bytes from r as /var/config/rest/downloads/<name> on BIG-IP,
+// in ranged chunks so that large certificates, iFiles and images can be uploaded.
+// It returns the local file path on BIG-IP. opts can be nil.
+func (bc *BIGIPContext) UploadReader(name string, r io.Reader, size int64, opts *UploadOptions) (string, error) {
+	return bc.upload(uploadUriPrefix+name, r, size, opts)
+}
+
+// upload sends the content to the file-transfer endpoint uri chunk by chunk.
+func (bc *BIGIPContext) upload(uri string, r io.Reader, size int64, opts *UploadOptions) (string, error) {
+	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(bc.Context)
+
+	o := UploadOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultUploadChunkSize
+	}
+	if o.Retries <= 0 {
+		o.Retries = DefaultUploadChunkRetries
+	}
+	if size <= 0 {
+		return "", fmt.Errorf("invalid size %d to upload %s", size, uri)
+	}
+
+	hasher := sha256.New()
+	reader := io.TeeReader(r, hasher)
+	buf := make([]byte, o.ChunkSize)
+
+	// chunks are retried here, not by each request.
+	cbc := *bc
+	cbc.retry = RetryPolicy{}
+	policy := RetryPolicy{MaxAttempts: o.Retries, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	localFilePath := ""
+	for start := int64(0); start < size; {
+		n := o.ChunkSize
+		if size-start < n {
+			n = size - start
+		}
+		if _, err := io.ReadFull(reader, buf[:n]); err != nil {
+			return "", fmt.Errorf("failed to read content at %d of %s: %w", start, uri, err)
+		}
+		end := start + n - 1
+		err := bc.retryWith(policy, fmt.Sprintf("upload %s %d-%d", uri, start, end), func() error {
+			path, err := cbc.uploadChunk(uri, buf[:n], start, end, size)
+			localFilePath = path
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		start = end + 1
+		if o.Progress != nil {
+			o.Progress(start, size)
+		}
+	}
+	slog.Debugf("uploaded %d bytes to %s", size, localFilePath)
+
+	if o.Verify {
+		if err := bc.verifyChecksum(localFilePath, hex.EncodeToString(hasher.Sum(nil))); err != nil {
+			return "", err
+		}
+	}
+	return localFilePath, nil
+}
+
+func (bc *BIGIPContext) uploadChunk(uri string, chunk []byte, start, end, size int64) (string, error) {
+	url := bc.URL + uri
+	method := "POST"
+	headers := map[string]string{
+		"Content-Type":  "application/octet-stream",
+		"Content-Range": fmt.Sprintf("%d-%d/%d", start, end, size),
+	}
+
+	code, resp, err := httpRequest(bc, url, method, string(chunk), headers)
+	if err != nil {
+		return "", err
+	}
+	if err := assertBigipResp(method, url, code, resp); err != nil {
+		return "", fmt.Errorf("error uploading %w", err)
+	}
+
+	var bipresp struct {
+		LocalFilePath string `json:"localFilePath"`
+	}
+	if err := json.Unmarshal(resp, &bipresp); err != nil {
+		return "", err
+	}
+	if end+1 == size && bipresp.LocalFilePath == "" {
+		return "", fmt.Errorf("localFilePath field not found")
+	}
+	return bipresp.LocalFilePath, nil
+}
+
+// verifyChecksum compares the sha256 checksum of the file on BIG-IP with the expected one.
+func (bc *BIGIPContext) verifyChecksum(path, expected string) error {
+	result, err := bc.Run(NewBashCommand("sha256sum", path))
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(result.Stdout)
+	if len(fields) == 0 || fields[0] != expected {
+		return fmt.Errorf("checksum mismatched for %s: expected %s, got '%s'", path, expected, strings.TrimSpace(result.Stdout))
+	}
+	return nil
+}
+
+func isUploadUri(uri string) bool {
+	return strings.HasPrefix(uri, "/mgmt/shared/file-transfer/") && strings.Contains(uri, "uploads/")
+}
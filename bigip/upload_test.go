@@ -0,0 +1,67 @@
+package f5_bigip
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBIGIPContext_UploadReader(t *testing.T) {
+	received := []byte{}
+	ranges := []string{}
+	failed := false
+	bc, done := newTestBIGIPContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mgmt/shared/file-transfer/uploads/myfile":
+			cr := r.Header.Get("Content-Range")
+			if cr == "4-7/10" && !failed {
+				failed = true
+				w.WriteHeader(503)
+				return
+			}
+			b, _ := io.ReadAll(r.Body)
+			received = append(received, b...)
+			ranges = append(ranges, cr)
+			fmt.Fprintf(w, `{"localFilePath":"/var/config/rest/downloads/myfile"}`)
+		case "/mgmt/tm/util/bash":
+			sum := sha256.Sum256(received)
+			stdout := hex.EncodeToString(sum[:]) + "  /var/config/rest/downloads/myfile\n"
+			fmt.Fprintf(w, `{"commandResult":"0 %d\n%s"}`, len(stdout), strings.ReplaceAll(stdout, "\n", `\n`))
+		default:
+			w.WriteHeader(404)
+		}
+	})
+	defer done()
+
+	content := "0123456789"
+	progress := []int64{}
+	path, err := bc.UploadReader("myfile", strings.NewReader(content), int64(len(content)), &UploadOptions{
+		ChunkSize: 4,
+		Progress:  func(uploaded, total int64) { progress = append(progress, uploaded) },
+		Verify:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/var/config/rest/downloads/myfile" {
+		t.Errorf("path = %s", path)
+	}
+	if string(received) != content {
+		t.Errorf("received %s, want %s", received, content)
+	}
+	if want := []string{"0-3/10", "4-7/10", "8-9/10"}; !reflect.DeepEqual(ranges, want) {
+		t.Errorf("ranges = %v, want %v", ranges, want)
+	}
+	if want := []int64{4, 8, 10}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+
+	if err := bc.verifyChecksum(path, "0000"); err == nil {
+		t.Errorf("checksum mismatch should fail")
+	}
+}
@@ -2,8 +2,7 @@ package f5_bigip
 
 import (
 	"context"
-	"crypto/tls"
-	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -49,6 +48,13 @@ func init() {
 		`gtm/pool/\w+`,
 		`gtm/wideip`,
 	}
+	// the retry policy of BIGIP created by NewBIGIP without WithRetryPolicy.
+	DefaultRetryPolicy = RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     30 * time.Second,
+		Deadline:       5 * time.Minute,
+	}
 	BIGIPiControlTimeCostTotal = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "bigip_icontrol_timecost_total",
@@ -66,69 +72,112 @@ func init() {
 	)
 }
 
+// New creates a BIGIP with Basic authentication and panics if BIG-IP is unavailable.
+// Use NewBIGIP instead for error returning and more options.
 func New(url, user, password string) *BIGIP {
-	bauth := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	bip, err := NewBIGIP(url, WithBasicAuth(user, password), WithPartitions("cis-c-tenant"))
+	if err != nil {
+		panic(err)
+	}
+	return bip
+}
+
+// NewBIGIP creates a BIGIP with the given options.
+// Unless WithLazyVersion is set, the version of BIG-IP is discovered as well,
+// which makes sure BIG-IP is reachable with the given settings.
+// The certificate verification is opt-in: without WithCABundle, WithFingerprints or a verifying WithTLSConfig,
+// the certificate of BIG-IP is not verified, and a warning is logged.
+func NewBIGIP(url string, opts ...Option) (*BIGIP, error) {
+	o := bigipOptions{
+		timeout: DefaultTimeout,
+		retry:   DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+	if o.authorization == "" && o.token == nil {
+		return nil, fmt.Errorf("no authentication specified for BIGIP %s", url)
+	}
+
+	tlsConfig := buildTLSConfig(&o)
 	bip := BIGIP{
 		URL:           url,
-		Authorization: bauth,
+		Authorization: o.authorization,
 		client: &http.Client{
 			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true,
-				},
+				TLSClientConfig: tlsConfig,
 			},
-			Timeout: 60 * time.Second,
+			Timeout: o.timeout,
 		},
+		token:        o.token,
+		version:      &versionCache{},
+		retry:        o.retry,
+		asyncTasks:   o.asyncTasks,
+		minimalPatch: o.minimalPatch,
+		syncer:       newResSyncer(),
+
+		maxTransCommands: o.maxTransCommands,
 	}
 
 	bc := &BIGIPContext{
 		bip,
 		context.TODO(),
 	}
-	sysinfo, err := bc.All("sys/version")
-	if err != nil {
-		panic(fmt.Errorf("BIGIP %s is unavailable: err %s, quit", bip.URL, err.Error()))
-	} else if sysinfo == nil {
-		panic(fmt.Errorf("BIGIP %s is unavailable: %s, quit", bip.URL, "cannot get sys info"))
-	} else {
-		bip.Version, err = bigipVersion(*sysinfo)
+	if !tlsVerified(tlsConfig) {
+		utils.LogFromContext(bc.Context).Warnf("the certificate of BIGIP %s is not verified, use WithCABundle or WithFingerprints", url)
+	}
+	if !o.lazyVersion {
+		version, err := bc.GetVersion()
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
+		bip.Version = version
 	}
 
-	if err := bc.DeployPartition("cis-c-tenant"); err != nil {
-		panic(err)
+	for _, p := range o.partitions {
+		if err := bc.DeployPartition(p); err != nil {
+			return nil, fmt.Errorf("failed to create partition %s on BIGIP %s: %w", p, url, err)
+		}
 	}
-	return &bip
+	return &bip, nil
 }
 
-func assertBigipResp20X(statusCode int, resp []byte) error {
-	sresp := string(resp)
-	switch statusCode {
-	case 401:
-		return utils.RetryErrorf("%d, %s", statusCode, sresp)
-	case 503:
-		return utils.RetryErrorf("%d, %s", statusCode, sresp)
-	case 500:
-		return utils.RetryErrorf("%d, %s", statusCode, sresp)
-	case 404:
-		for _, p := range []string{
-			".*URI path .* not registered.*",
-			".*Public URI path not registered: .*",
-		} {
-			if matched, err := regexp.Match(p, resp); err == nil && matched {
-				return utils.RetryErrorf("%d, %s", statusCode, sresp)
-			}
+// GetVersion returns the version of BIG-IP, which is queried and cached at the first call.
+func (bc *BIGIPContext) GetVersion() (string, error) {
+	if bc.Version != "" {
+		return bc.Version, nil
+	}
+	if bc.version == nil {
+		bc.version = &versionCache{}
+	}
+	bc.version.mutex.Lock()
+	defer bc.version.mutex.Unlock()
+
+	if bc.version.version == "" {
+		sysinfo, err := bc.All("sys/version")
+		if err != nil {
+			return "", fmt.Errorf("BIGIP %s is unavailable: err %w", bc.URL, err)
+		} else if sysinfo == nil {
+			return "", fmt.Errorf("BIGIP %s is unavailable: %s", bc.URL, "cannot get sys info")
 		}
-		return fmt.Errorf("%d, %s", statusCode, sresp)
-	default:
-		if int(statusCode/200) != 1 {
-			return fmt.Errorf("%d, %s", statusCode, sresp)
-		} else {
-			return nil
+		version, err := bigipVersion(*sysinfo)
+		if err != nil {
+			return "", err
 		}
+		bc.version.version = version
+	}
+	bc.Version = bc.version.version
+	return bc.Version, nil
+}
+
+// assertBigipResp20X returns nil for successful responses, otherwise, a *BigipError.
+func assertBigipResp20X(statusCode int, resp []byte) error {
+	if int(statusCode/200) == 1 {
+		return nil
 	}
+	return newBigipError(statusCode, resp)
 
 	// kinds of error statuses from BIG-IP
 
@@ -149,17 +198,13 @@ func assertBigipResp20X(statusCode int, resp []byte) error {
 }
 
 func bigipVersion(sysinfo map[string]interface{}) (string, error) {
-	if entries, f := sysinfo["entries"]; f {
-		if version0, f := entries.(map[string]interface{})["https://localhost/mgmt/tm/sys/version/0"]; f {
-			if nestedStats, f := version0.(map[string]interface{})["nestedStats"]; f {
-				if entries, f := nestedStats.(map[string]interface{})["entries"]; f {
-					if version, f := entries.(map[string]interface{})["Version"]; f {
-						if description, f := version.(map[string]interface{})["description"]; f {
-							return description.(string), nil
-						}
-					}
-				}
-			}
+	stats, err := flattenStats(sysinfo)
+	if err != nil {
+		return "", err
+	}
+	if version0, f := stats["0"]; f {
+		if version, f := version0.Descriptions["Version"]; f {
+			return version, nil
 		}
 	}
 	return "", fmt.Errorf("entries not found")
@@ -261,8 +306,18 @@ func sortCmds(unsorted []RestRequest, reversed bool) []RestRequest {
 	return sorted
 }
 
-func httpRequest(ctx context.Context, client *http.Client, url, method, payload string, headers map[string]string) (int, []byte, error) {
-	slog := utils.LogFromContext(ctx)
+// httpRequest sends the request to BIG-IP with the authentication headers attached.
+// In token mode, a 401 response drops the token and the request is sent once more with a new one.
+// Retryable failures are retried following bc.retry, except the ones within a transaction,
+// and the 500 responses of the non-idempotent methods, see idempotent.
+func httpRequest(bc *BIGIPContext, url, method, payload string, headers map[string]string) (int, []byte, error) {
+	code, _, resp, err := httpRequestWithHeaders(bc, url, method, payload, headers)
+	return code, resp, err
+}
+
+// httpRequestWithHeaders does the same as httpRequest, and returns the response headers as well.
+func httpRequestWithHeaders(bc *BIGIPContext, url, method, payload string, headers map[string]string) (int, http.Header, []byte, error) {
+	slog := utils.LogFromContext(bc.Context)
 
 	tf := utils.TimeItTrace(slog)
 	defer func() {
@@ -287,7 +342,79 @@ func httpRequest(ctx context.Context, client *http.Client, url, method, payload
 		BIGIPiControlTimeCostTotal.WithLabelValues(method, rec).Add(tc)
 	}()
 
-	return utils.HttpRequest(client, url, method, payload, headers)
+	var code int
+	var header http.Header
+	var resp []byte
+	var err error
+	send := func() error {
+		code, header, resp, err = authedRequest(bc, url, method, payload, headers)
+		if err == nil && code == 401 && bc.token != nil {
+			slog.Debugf("got 401 from %s, re-acquiring token", bc.URL)
+			code, header, resp, err = authedRequest(bc, url, method, payload, headers)
+		}
+		if err != nil {
+			return err
+		}
+		aerr := assertBigipResp20X(code, resp)
+		if aerr != nil && code == http.StatusInternalServerError && !idempotent(method) {
+			// the POST or PATCH may have been applied, it's not sent again.
+			return nil
+		}
+		return aerr
+	}
+	if inTransaction(url, headers) {
+		send()
+	} else if rerr := bc.withRetry(fmt.Sprintf("%s %s", method, url), send); rerr != nil && bc.ctx().Err() != nil {
+		return code, header, resp, rerr
+	}
+	return code, header, resp, err
+}
+
+// requestJSON sends body as JSON to the iControl uri and returns the parsed JSON response, body can be nil.
+func (bc *BIGIPContext) requestJSON(method, uri string, body interface{}) (map[string]interface{}, error) {
+	url := bc.URL + uri
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	payload := ""
+	if body != nil {
+		bbody, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = string(bbody)
+	}
+
+	code, resp, err := httpRequest(bc, url, method, payload, headers)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertBigipResp(method, url, code, resp); err != nil {
+		return nil, err
+	}
+
+	jresp := map[string]interface{}{}
+	if len(resp) > 0 {
+		if err := json.Unmarshal(resp, &jresp); err != nil {
+			return nil, fmt.Errorf("invalid response of %s %s: %s", method, url, err.Error())
+		}
+	}
+	return jresp, nil
+}
+
+func authedRequest(bc *BIGIPContext, url, method, payload string, headers map[string]string) (int, http.Header, []byte, error) {
+	hdrs, err := bc.authHeaders()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	for k, v := range headers {
+		hdrs[k] = v
+	}
+	code, header, resp, err := utils.HttpRequestWithHeaders(bc.ctx(), bc.client, url, method, payload, hdrs)
+	if err == nil && code == 401 {
+		bc.invalidateToken(hdrs["X-F5-Auth-Token"])
+	}
+	return code, header, resp, err
 }
 
 func GatherKinds(ocfg, ncfg *map[string]interface{}) []string {
@@ -1,6 +1,9 @@
 package f5_bigip
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strconv"
 	"strings"
@@ -607,3 +610,16 @@ func TestKindIsSupported(t *testing.T) {
 		})
 	}
 }
+
+// newTestBIGIPContext serves handler as BIG-IP, and returns the BIGIPContext of it with basic auth, lazy version
+// and no retry unless opts say otherwise, and the func closing the server.
+func newTestBIGIPContext(t *testing.T, handler http.HandlerFunc, opts ...Option) (*BIGIPContext, func()) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	bip, err := NewBIGIP(server.URL, append([]Option{WithBasicAuth("admin", "admin"), WithLazyVersion(), WithRetryPolicy(RetryPolicy{})}, opts...)...)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return &BIGIPContext{BIGIP: *bip, Context: context.TODO()}, server.Close
+}
@@ -1,14 +1,76 @@
 package f5_bigip
 
 import (
+	"errors"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
+	// ErrStopListing can be returned by the callback of List to stop listing without error.
+	ErrStopListing = errors.New("stop listing")
 	// slog                       *utils.SLOG
 	ResOrder                   []string
+	DefaultRetryPolicy         RetryPolicy
 	BIGIPiControlTimeCostTotal *prometheus.GaugeVec
 	BIGIPiControlTimeCostCount *prometheus.GaugeVec
 )
 
+// taskPollPolicy is the backoff of polling the status of long-running tasks.
+var taskPollPolicy = RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+// scheduledRetryPolicy retries the failed runs of the requests with ScheduleIt, whatever the error is.
+var scheduledRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
 const TmUriPrefix = "/mgmt/tm"
+
+// DefaultListPageSize is the page size of listing the existing resources of a partition.
+const DefaultListPageSize = 500
+
+const (
+	as3UriPrefix = "/mgmt/shared/appsvcs"
+	doUriPrefix  = "/mgmt/shared/declarative-onboarding"
+	tsUriPrefix  = "/mgmt/shared/telemetry"
+)
+
+const (
+	// DefaultUploadChunkSize fits the 1 MB limit of the file-transfer worker.
+	DefaultUploadChunkSize = 1024 * 1024
+	// DefaultUploadChunkRetries is the number of attempts to upload a chunk.
+	DefaultUploadChunkRetries = 3
+	// uploadUriPrefix is where the files for sys/file, iFiles and images are uploaded to,
+	// they're saved into /var/config/rest/downloads/ on BIG-IP.
+	uploadUriPrefix = "/mgmt/shared/file-transfer/uploads/"
+
+	// DefaultDownloadChunkSize is the size of each ranged request of downloading.
+	DefaultDownloadChunkSize = 1024 * 1024
+	// DefaultDownloadChunkRetries is the number of attempts to download a chunk.
+	DefaultDownloadChunkRetries = 3
+	// downloadUriPrefix serves the files in /var/config/rest/downloads/ on BIG-IP.
+	downloadUriPrefix = "/mgmt/shared/file-transfer/downloads/"
+	// ucsDownloadUriPrefix serves the UCS archives in /var/local/ucs/ on BIG-IP.
+	ucsDownloadUriPrefix = "/mgmt/shared/file-transfer/ucs-downloads/"
+	// imageDownloadUriPrefix serves the software images in /shared/images/ on BIG-IP.
+	imageDownloadUriPrefix = "/mgmt/cm/autodeploy/software-image-downloads/"
+	// ucsUploadUriPrefix is where the UCS archives are uploaded to, they're saved into /var/local/ucs/ on BIG-IP.
+	ucsUploadUriPrefix = "/mgmt/shared/file-transfer/ucs-uploads/"
+)
+
+const (
+	// DefaultTimeout is the timeout of each iControl call if not specified by WithTimeout.
+	DefaultTimeout = 60 * time.Second
+	// DefaultLoginProvider is the loginProviderName for local BIG-IP users.
+	DefaultLoginProvider = "tmos"
+	// tokenRefreshAhead is how long before expiration the token is re-acquired.
+	tokenRefreshAhead = 60 * time.Second
+	// transCleanupTimeout limits the time of deleting a transaction abandoned by cancellation.
+	transCleanupTimeout = 10 * time.Second
+	// taskCleanupTimeout limits the time of cancelling or deleting a task after it's finished or abandoned.
+	taskCleanupTimeout = 10 * time.Second
+	// scheduledRunTimeout limits the time of each attempt to run a request with ScheduleIt.
+	scheduledRunTimeout = 60 * time.Second
+)
+
+// maxFinishedResults limits the results of the finished one-shot requests kept by the syncer.
+var maxFinishedResults = 100
@@ -0,0 +1,82 @@
+package deployer
+
+import (
+	"fmt"
+
+	f5_bigip "github.com/f5devcentral/f5-bigip-rest-go/bigip"
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// deployAS3 deploys the AS3 or ADC declaration r.To, the tenants in r.From but not in r.To are deleted.
+func deployAS3(bc *f5_bigip.BIGIPContext, r DeployRequest) ([]TenantResult, error) {
+	slog := utils.LogFromContext(bc.Context)
+	ocfgs, ncfgs := r.From, r.To
+
+	if ncfgs == nil && ocfgs == nil {
+		return nil, fmt.Errorf("as3 body is empty, quit as error")
+	}
+	info, err := bc.AS3Info()
+	if err != nil {
+		return nil, err
+	}
+	slog.Debugf("as3 version on %s: %s-%s", bc.URL, info.Version, info.Release)
+
+	obsoleted := []string{}
+	if ocfgs != nil {
+		ntenants := map[string]bool{}
+		if ncfgs != nil {
+			for _, t := range f5_bigip.AS3Tenants(*ncfgs) {
+				ntenants[t] = true
+			}
+		}
+		for _, t := range f5_bigip.AS3Tenants(*ocfgs) {
+			if !ntenants[t] {
+				obsoleted = append(obsoleted, t)
+			}
+		}
+	}
+
+	var results []f5_bigip.AS3Result
+	if ncfgs == nil {
+		slog.Infof("deleting as3 tenants: %s", obsoleted)
+		results, err = bc.DeleteAS3Tenants(obsoleted...)
+	} else {
+		results, err = bc.DeployAS3(withEmptyTenants(*ncfgs, obsoleted))
+	}
+
+	tresults := []TenantResult{}
+	for _, result := range results {
+		tresults = append(tresults, TenantResult{
+			BIGIP:   bc.URL,
+			Tenant:  result.Tenant,
+			Code:    result.Code,
+			Message: result.Message,
+		})
+	}
+	return tresults, err
+}
+
+// withEmptyTenants returns a copy of the declaration with the tenants declared empty, which deletes them.
+func withEmptyTenants(declaration map[string]interface{}, tenants []string) map[string]interface{} {
+	if len(tenants) == 0 {
+		return declaration
+	}
+	decl := map[string]interface{}{}
+	for k, v := range declaration {
+		decl[k] = v
+	}
+	adc := decl
+	if decl["class"] == "AS3" {
+		adc = map[string]interface{}{}
+		if inner, ok := decl["declaration"].(map[string]interface{}); ok {
+			for k, v := range inner {
+				adc[k] = v
+			}
+		}
+		decl["declaration"] = adc
+	}
+	for _, t := range tenants {
+		adc[t] = map[string]interface{}{"class": "Tenant"}
+	}
+	return decl
+}
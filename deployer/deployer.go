@@ -2,69 +2,154 @@ package deployer
 
 import (
 	"fmt"
+	"time"
 
 	f5_bigip "github.com/f5devcentral/f5-bigip-rest-go/bigip"
 	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-func deploy(bc *f5_bigip.BIGIPContext, partition string, ocfgs, ncfgs *map[string]interface{}, as3mode bool) error {
+func init() {
+	RegisterDeployMode(DeployMode_Native, deployNative)
+	RegisterDeployMode(DeployMode_AS3, deployAS3)
+	RegisterDeployMode(DeployMode_DO, deployDO)
+	RegisterDeployMode(DeployMode_TS, deployTS)
+
+	DriftResourcesCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "deployer_drift_resources_count",
+			Help: "number of drifted resources found by the last check of reconciler",
+		},
+		[]string{"bigip", "partition"},
+	)
+
+	DriftChecksCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "deployer_drift_checks_count",
+			Help: "total number of drift checks of reconciler by result",
+		},
+		[]string{"bigip", "partition", "result"},
+	)
+}
+
+// RegisterDeployMode adds a backend for DeployRequest.Mode, or replaces the existing one.
+// It's not thread-safe, and should be called before Deployer starts, like in init().
+func RegisterDeployMode(mode DeployMode, f DeployFunc) {
+	deployFuncs[mode] = f
+}
+
+func deploy(bc *f5_bigip.BIGIPContext, r DeployRequest) ([]TenantResult, error) {
 	defer utils.TimeItToPrometheus()()
 
-	if as3mode {
-		if ncfgs == nil {
-			return fmt.Errorf("as3 body is empty, quit as error")
-		}
-		switch (*ncfgs)["class"] {
-		case "AS3":
-			return bc.Restcall("/mgmt/shared/appsvcs/declare", "POST", nil, *ncfgs)
-		default:
-			return fmt.Errorf("not support, class %s", (*ncfgs)["class"])
-		}
-	} else {
-		kinds := f5_bigip.GatherKinds(ocfgs, ncfgs)
-		existings, err := bc.GetExistingResources(partition, kinds)
-		if err != nil {
-			fmt.Printf("failed to get existing resource of kind %s for partition %s: %s", kinds, partition, err.Error())
-			panic(err)
-		}
+	mode := r.mode()
+	f, found := deployFuncs[mode]
+	if !found {
+		return nil, fmt.Errorf("not support, deploy mode %s", mode)
+	}
+	return f(bc, r)
+}
 
-		cmds, err := bc.GenRestRequests(partition, ocfgs, ncfgs, existings)
-		if err != nil {
-			return err
-		}
-		return bc.DoRestRequests(cmds)
+func deployNative(bc *f5_bigip.BIGIPContext, r DeployRequest) ([]TenantResult, error) {
+	kinds := f5_bigip.GatherKinds(r.From, r.To)
+	existings, err := bc.GetExistingResources(r.Partition, kinds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing resources of partition %s: %w", r.Partition, err)
 	}
+
+	cmds, err := bc.GenRestRequests(r.Partition, r.From, r.To, existings)
+	if err != nil {
+		return nil, err
+	}
+	if r.Context.Value(CtxKey_DryRun) != nil {
+		slog := utils.LogFromContext(r.Context)
+		slog.Infof("dry run: %s", f5_bigip.NewPlan(*cmds, existings))
+		return nil, bc.ValidateRestRequests(cmds)
+	}
+	return nil, bc.DoRestRequests(cmds)
+}
+
+// mode returns the deploy mode of the request, with the deprecated AS3 field honored.
+func (r DeployRequest) mode() DeployMode {
+	if r.Mode != "" {
+		return r.Mode
+	}
+	if r.AS3 {
+		return DeployMode_AS3
+	}
+	return DeployMode_Native
 }
 
 func HandleRequest(bc *f5_bigip.BIGIPContext, r DeployRequest) error {
+	_, err := handleRequest(bc, r)
+	return err
+}
+
+// handleRequest does the same as HandleRequest, and returns the per-tenant results of declarative deployments.
+func handleRequest(bc *f5_bigip.BIGIPContext, r DeployRequest) ([]TenantResult, error) {
 	specified := r.Context.Value(CtxKey_SpecifiedBIGIP)
 	slog := utils.LogFromContext(r.Context)
 	if specified != nil && specified.(string) != bc.URL {
 		slog.Infof("skipping bigip %s", bc.URL)
-		return nil
+		return nil, nil
 	}
 
+	if r.Context.Value(CtxKey_DryRun) != nil {
+		if r.mode() != DeployMode_Native {
+			return nil, fmt.Errorf("dry run is not supported in mode %s", r.mode())
+		}
+		slog.Infof("dry run: validating only")
+		results, err := deploy(bc, r)
+		if err != nil {
+			return results, fmt.Errorf("failed to validate deployment to %s: %w", bc.URL, err)
+		}
+		return results, nil
+	}
+	if v := r.Context.Value(CtxKey_SnapshotUCS); v != nil {
+		name, _ := v.(string)
+		if name == "" {
+			name = fmt.Sprintf("%s-%s.ucs", r.Partition, time.Now().Format("20060102-150405"))
+		}
+		slog.Infof("snapshotting ucs: %s", name)
+		if err := bc.SaveUCS(name, nil); err != nil {
+			return nil, fmt.Errorf("failed to snapshot ucs %s on %s: %w", name, bc.URL, err)
+		}
+	}
 	if r.Context.Value(CtxKey_CreatePartition) != nil {
 		slog.Infof("creating partition: %s", r.Partition)
 		if err := bc.DeployPartition(r.Partition); err != nil {
-			return fmt.Errorf("failed to deploy partition %s: %s", r.Partition, err.Error())
+			return nil, fmt.Errorf("failed to deploy partition %s: %w", r.Partition, err)
 		}
 	}
-	if err := deploy(bc, r.Partition, r.From, r.To, r.AS3); err != nil {
-		return fmt.Errorf("failed to do deployment to %s: %s", bc.URL, err.Error())
+	results, err := deploy(bc, r)
+	if err != nil {
+		return results, fmt.Errorf("failed to do deployment to %s: %w", bc.URL, err)
 	}
 	if r.Context.Value(CtxKey_DeletePartition) != nil {
 		slog.Infof("deleting partition: %s", r.Partition)
 		if err := bc.DeletePartition(r.Partition); err != nil {
-			return fmt.Errorf("failed to deploy partition %s: %s", r.Partition, err.Error())
+			return results, fmt.Errorf("failed to delete partition %s: %w", r.Partition, err)
 		}
 	}
-	return nil
+	return results, nil
 }
 
-func Deployer(stopCh chan struct{}, bigips []*f5_bigip.BIGIP) (*utils.DeployQueue, *utils.DeployQueue) {
+// WithReconciler makes Deployer record the configs applied, and check their drift with the reconciler.
+func WithReconciler(rc *Reconciler) DeployerOption {
+	return func(o *deployerOptions) {
+		o.reconciler = rc
+	}
+}
+
+func Deployer(stopCh chan struct{}, bigips []*f5_bigip.BIGIP, opts ...DeployerOption) (*utils.DeployQueue, *utils.DeployQueue) {
+	o := deployerOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	pendingDeploys := utils.NewDeployQueue()
 	doneDeploys := utils.NewDeployQueue()
+	if o.reconciler != nil {
+		go o.reconciler.run(stopCh, pendingDeploys)
+	}
 	go func() {
 		for {
 			select {
@@ -82,16 +167,21 @@ func Deployer(stopCh chan struct{}, bigips []*f5_bigip.BIGIP) (*utils.DeployQueu
 				slog := utils.LogFromContext(r.Context)
 				slog.Infof("Processing request: %s", r.Meta)
 				errs := []error{}
+				results := []TenantResult{}
 				for _, bigip := range bigips {
 					bc := &f5_bigip.BIGIPContext{BIGIP: *bigip, Context: r.Context}
-					if err := HandleRequest(bc, r); err != nil {
+					rs, err := handleRequest(bc, r)
+					results = append(results, rs...)
+					if err != nil {
 						// report status
 						slog.Errorf(err.Error())
 						errs = append(errs, err)
+					} else if o.reconciler != nil {
+						o.reconciler.record(bigip, r)
 					}
 				}
 
-				resp := DeployResponse{DeployRequest: r, Status: utils.MergeErrors(errs)}
+				resp := DeployResponse{DeployRequest: r, Status: utils.MergeErrors(errs), Results: results}
 				doneDeploys.Add(resp)
 			}
 		}
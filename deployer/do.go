@@ -0,0 +1,28 @@
+package deployer
+
+import (
+	"fmt"
+
+	f5_bigip "github.com/f5devcentral/f5-bigip-rest-go/bigip"
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// deployDO onboards BIG-IP with the Declarative Onboarding declaration r.To.
+// DO has no deletion, r.From is ignored.
+func deployDO(bc *f5_bigip.BIGIPContext, r DeployRequest) ([]TenantResult, error) {
+	slog := utils.LogFromContext(bc.Context)
+
+	if r.To == nil {
+		return nil, fmt.Errorf("do body is empty, quit as error")
+	}
+	slog.Infof("onboarding %s with do", bc.URL)
+	result, err := bc.DeployDO(*r.To)
+	if result == nil {
+		return nil, err
+	}
+	return []TenantResult{{
+		BIGIP:   bc.URL,
+		Code:    result.Code,
+		Message: fmt.Sprintf("%s, %s", result.Status, result.Message),
+	}}, err
+}
@@ -0,0 +1,252 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	f5_bigip "github.com/f5devcentral/f5-bigip-rest-go/bigip"
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// NewReconciler creates a Reconciler with the policy for all partitions, use SetPolicy for a specific one.
+// Pass it to Deployer WithReconciler, and get the DriftEvent from Events.
+// Only the resources in the applied configs are compared, the ones created in the partitions out of band
+// are neither reported nor deleted.
+func NewReconciler(policy ReconcilePolicy) (*Reconciler, error) {
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	return &Reconciler{
+		Events:   utils.NewDeployQueue(),
+		policy:   policy,
+		policies: map[string]ReconcilePolicy{},
+		applied:  map[string]*appliedConfig{},
+	}, nil
+}
+
+// SetPolicy sets the policy of the partition, instead of the one passed to NewReconciler.
+func (rc *Reconciler) SetPolicy(partition string, policy ReconcilePolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	rc.policies[partition] = policy
+	return nil
+}
+
+// validate checks the policy and compiles the kinds of Ignores, on a copy of them.
+func (p *ReconcilePolicy) validate() error {
+	if p.Interval < 0 {
+		return fmt.Errorf("invalid reconcile interval: %s", p.Interval)
+	}
+	ignores := []DriftIgnore{}
+	for _, ignore := range p.Ignores {
+		rex, err := regexp.Compile("^(?:" + ignore.Kind + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid kind of drift ignore %s: %w", ignore.Kind, err)
+		}
+		ignore.kind = rex
+		ignores = append(ignores, ignore)
+	}
+	p.Ignores = ignores
+	return nil
+}
+
+func (p ReconcilePolicy) interval() time.Duration {
+	if p.Interval == 0 {
+		return DefaultReconcileInterval
+	}
+	return p.Interval
+}
+
+func (rc *Reconciler) policyOf(partition string) ReconcilePolicy {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	if p, f := rc.policies[partition]; f {
+		return p
+	}
+	return rc.policy
+}
+
+// record keeps the To config of the request successfully deployed to the BIG-IP, or forgets it if To is nil.
+// The requests not deployed in DeployMode_Native, dry run or of re-applying are ignored.
+func (rc *Reconciler) record(bigip *f5_bigip.BIGIP, r DeployRequest) {
+	if r.mode() != DeployMode_Native || r.Context.Value(CtxKey_DryRun) != nil || r.Context.Value(ctxKey_Reconcile) != nil {
+		return
+	}
+	if specified := r.Context.Value(CtxKey_SpecifiedBIGIP); specified != nil && specified.(string) != bigip.URL {
+		return
+	}
+	key := bigip.URL + " " + r.Partition
+	interval := rc.policyOf(r.Partition).interval()
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	if r.To == nil {
+		delete(rc.applied, key)
+		DriftResourcesCount.DeleteLabelValues(bigip.URL, r.Partition)
+		return
+	}
+	copied, err := utils.DeepCopy(*r.To)
+	if err != nil {
+		utils.LogFromContext(r.Context).Errorf("failed to record the config of partition %s: %s", r.Partition, err.Error())
+		return
+	}
+	a, f := rc.applied[key]
+	if !f {
+		a = &appliedConfig{bigip: bigip, partition: r.Partition}
+		rc.applied[key] = a
+	}
+	a.config = copied.(map[string]interface{})
+	// the logger is kept, without the cancellation of the request.
+	a.context = context.WithValue(context.Background(), utils.CtxKey_Logger, utils.LogFromContext(r.Context))
+	a.generation++
+	a.nextCheck = time.Now().Add(interval)
+}
+
+// run checks the due partitions concurrently, so that a slow BIG-IP doesn't delay the checks of others.
+func (rc *Reconciler) run(stopCh chan struct{}, pendingDeploys *utils.DeployQueue) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(reconcileTick):
+		}
+		var wg sync.WaitGroup
+		for _, a := range rc.dues(time.Now()) {
+			wg.Add(1)
+			go func(a appliedConfig) {
+				defer wg.Done()
+				rc.check(a, pendingDeploys)
+			}(a)
+		}
+		wg.Wait()
+	}
+}
+
+// dues returns the copies of the applied configs due to check, and schedules their next checks.
+func (rc *Reconciler) dues(now time.Time) []appliedConfig {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	dues := []appliedConfig{}
+	for _, a := range rc.applied {
+		if now.Before(a.nextCheck) {
+			continue
+		}
+		policy := rc.policy
+		if p, f := rc.policies[a.partition]; f {
+			policy = p
+		}
+		a.nextCheck = now.Add(policy.interval())
+		dues = append(dues, *a)
+	}
+	return dues
+}
+
+// current tells if the config checked is still the last one applied.
+func (rc *Reconciler) current(a appliedConfig) bool {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	latest, f := rc.applied[a.bigip.URL+" "+a.partition]
+	return f && latest.generation == a.generation
+}
+
+// check compares the applied config with BIG-IP in reconcileCheckTimeout, and reports the drift.
+// The config is the From and To of GenPlan, so the plan has no Delete: the resources not in it are not compared.
+// The drifted config is queued to Deployer with AutoApply, so that it's not deployed along with other requests.
+func (rc *Reconciler) check(a appliedConfig, pendingDeploys *utils.DeployQueue) {
+	defer utils.TimeItToPrometheus()()
+	slog := utils.LogFromContext(a.context)
+	policy := rc.policyOf(a.partition)
+	url := a.bigip.URL
+
+	desired := ignoreDrift(a.config, policy.Ignores)
+	ctx, cancel := context.WithTimeout(a.context, reconcileCheckTimeout)
+	defer cancel()
+	bc := &f5_bigip.BIGIPContext{BIGIP: *a.bigip, Context: ctx}
+	plan, _, err := bc.GenPlan(a.partition, &desired, &desired)
+	if !rc.current(a) {
+		slog.Debugf("partition %s on %s is applied again while checking drift, skipping", a.partition, url)
+		return
+	}
+
+	event := DriftEvent{BIGIP: url, Partition: a.partition, Time: time.Now(), Plan: plan}
+	if err != nil {
+		event.Status = fmt.Errorf("failed to check drift of partition %s on %s: %w", a.partition, url, err)
+		slog.Errorf(event.Status.Error())
+		DriftChecksCount.WithLabelValues(url, a.partition, "failed").Inc()
+		rc.Events.Add(event)
+		return
+	}
+
+	drifted := len(plan.Create) + len(plan.Update) + len(plan.Delete)
+	DriftResourcesCount.WithLabelValues(url, a.partition).Set(float64(drifted))
+	if drifted == 0 {
+		slog.Debugf("partition %s on %s is in sync", a.partition, url)
+		DriftChecksCount.WithLabelValues(url, a.partition, "in-sync").Inc()
+		return
+	}
+	DriftChecksCount.WithLabelValues(url, a.partition, "drifted").Inc()
+	slog.Warnf("partition %s on %s drifted: %s", a.partition, url, plan)
+
+	if policy.AutoApply {
+		ctx := context.WithValue(a.context, CtxKey_SpecifiedBIGIP, url)
+		ctx = context.WithValue(ctx, ctxKey_Reconcile, "yes")
+		pendingDeploys.Add(DeployRequest{
+			Meta:      fmt.Sprintf("reconciling drift of partition %s on %s", a.partition, url),
+			From:      &desired,
+			To:        &desired,
+			Partition: a.partition,
+			Mode:      DeployMode_Native,
+			Context:   ctx,
+		})
+		event.Reapplying = true
+	}
+	rc.Events.Add(event)
+}
+
+// ignoreDrift returns a copy of the config without the kinds or fields ignored.
+func ignoreDrift(cfg map[string]interface{}, ignores []DriftIgnore) map[string]interface{} {
+	copied, _ := utils.DeepCopy(cfg)
+	desired, _ := copied.(map[string]interface{})
+	if len(ignores) == 0 {
+		return desired
+	}
+	for _, ress := range desired {
+		resm, ok := ress.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for tn, body := range resm {
+			kind := tn
+			if i := strings.LastIndex(tn, "/"); i >= 0 {
+				kind = tn[:i]
+			}
+			for _, ignore := range ignores {
+				if !ignore.kind.MatchString(kind) {
+					continue
+				}
+				if len(ignore.Fields) == 0 {
+					delete(resm, tn)
+					break
+				}
+				if props, ok := body.(map[string]interface{}); ok {
+					for _, field := range ignore.Fields {
+						delete(props, field)
+					}
+				}
+			}
+		}
+	}
+	return desired
+}
@@ -0,0 +1,226 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	f5_bigip "github.com/f5devcentral/f5-bigip-rest-go/bigip"
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+func newTestReconciler(t *testing.T, policy ReconcilePolicy, handler http.HandlerFunc) (*Reconciler, *f5_bigip.BIGIP, func()) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	bigip, err := f5_bigip.NewBIGIP(server.URL, f5_bigip.WithBasicAuth("admin", "admin"), f5_bigip.WithLazyVersion())
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	rc, err := NewReconciler(policy)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return rc, bigip, server.Close
+}
+
+func testPoolConfig(lbMode string) *map[string]interface{} {
+	return &map[string]interface{}{
+		"": map[string]interface{}{
+			"ltm/pool/pool1": map[string]interface{}{"name": "pool1", "loadBalancingMode": lbMode},
+		},
+	}
+}
+
+func TestNewReconciler_invalid(t *testing.T) {
+	if _, err := NewReconciler(ReconcilePolicy{Interval: -time.Second}); err == nil {
+		t.Errorf("expected error of negative interval")
+	}
+	if _, err := NewReconciler(ReconcilePolicy{Ignores: []DriftIgnore{{Kind: "ltm/(pool"}}}); err == nil {
+		t.Errorf("expected error of invalid kind")
+	}
+}
+
+func TestReconciler_record(t *testing.T) {
+	rc, bigip, done := newTestReconciler(t, ReconcilePolicy{Interval: time.Minute}, func(w http.ResponseWriter, r *http.Request) {})
+	defer done()
+	key := bigip.URL + " p1"
+
+	skipped := []DeployRequest{
+		{Partition: "p1", To: testPoolConfig("round-robin"), Mode: DeployMode_AS3, Context: context.TODO()},
+		{Partition: "p1", To: testPoolConfig("round-robin"), Context: context.WithValue(context.TODO(), CtxKey_DryRun, "yes")},
+		{Partition: "p1", To: testPoolConfig("round-robin"), Context: context.WithValue(context.TODO(), ctxKey_Reconcile, "yes")},
+		{Partition: "p1", To: testPoolConfig("round-robin"), Context: context.WithValue(context.TODO(), CtxKey_SpecifiedBIGIP, "https://192.0.2.1")},
+	}
+	for _, r := range skipped {
+		rc.record(bigip, r)
+	}
+	if len(rc.applied) != 0 {
+		t.Fatalf("unexpected applied: %v", rc.applied)
+	}
+
+	cfg := testPoolConfig("round-robin")
+	rc.record(bigip, DeployRequest{Partition: "p1", To: cfg, Context: context.TODO()})
+	a := rc.applied[key]
+	if a == nil || a.generation != 1 || !reflect.DeepEqual(a.config, *cfg) {
+		t.Fatalf("unexpected applied: %v", a)
+	}
+	// the recorded config is a copy.
+	(*cfg)[""].(map[string]interface{})["ltm/pool/pool1"] = nil
+	if reflect.DeepEqual(a.config, *cfg) {
+		t.Errorf("recorded config is changed with the request")
+	}
+
+	now := time.Now()
+	if dues := rc.dues(now); len(dues) != 0 {
+		t.Errorf("unexpected dues before interval: %v", dues)
+	}
+	dues := rc.dues(now.Add(time.Minute))
+	if len(dues) != 1 || !rc.current(dues[0]) {
+		t.Fatalf("unexpected dues after interval: %v", dues)
+	}
+	if again := rc.dues(now.Add(time.Minute)); len(again) != 0 {
+		t.Errorf("next check is not scheduled: %v", again)
+	}
+
+	rc.record(bigip, DeployRequest{Partition: "p1", To: testPoolConfig("least-connections-member"), Context: context.TODO()})
+	if a.generation != 2 || rc.current(dues[0]) {
+		t.Errorf("due of the former config is still current, generation %d", a.generation)
+	}
+
+	rc.record(bigip, DeployRequest{Partition: "p1", To: nil, Context: context.TODO()})
+	if _, f := rc.applied[key]; f || rc.current(dues[0]) {
+		t.Errorf("deleted partition is still recorded")
+	}
+}
+
+func Test_ignoreDrift(t *testing.T) {
+	policy := ReconcilePolicy{Ignores: []DriftIgnore{
+		{Kind: "ltm/virtual"},
+		{Kind: "ltm/pool", Fields: []string{"members"}},
+	}}
+	if err := policy.validate(); err != nil {
+		t.Fatal(err)
+	}
+	cfg := map[string]interface{}{
+		"f1": map[string]interface{}{
+			"ltm/virtual/vs1":          map[string]interface{}{"name": "vs1"},
+			"ltm/virtual-address/va1":  map[string]interface{}{"name": "va1"},
+			"ltm/pool/pool1":           map[string]interface{}{"name": "pool1", "members": []interface{}{}},
+			"ltm/monitor/http/monitor": map[string]interface{}{"name": "monitor"},
+		},
+	}
+	want := map[string]interface{}{
+		"f1": map[string]interface{}{
+			"ltm/virtual-address/va1":  map[string]interface{}{"name": "va1"},
+			"ltm/pool/pool1":           map[string]interface{}{"name": "pool1"},
+			"ltm/monitor/http/monitor": map[string]interface{}{"name": "monitor"},
+		},
+	}
+	if got := ignoreDrift(cfg, policy.Ignores); !reflect.DeepEqual(got, want) {
+		t.Errorf("ignoreDrift() = %v, want %v", got, want)
+	}
+	if len(cfg["f1"].(map[string]interface{})) != 4 {
+		t.Errorf("the original config is changed: %v", cfg)
+	}
+}
+
+func TestReconciler_check(t *testing.T) {
+	rc, bigip, done := newTestReconciler(t, ReconcilePolicy{AutoApply: true}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/mgmt/tm/sys/folder":
+			fmt.Fprint(w, `{"items":[]}`)
+		case r.Method == "GET" && r.URL.Path == "/mgmt/tm/ltm/pool":
+			fmt.Fprint(w, `{"items":[{"name":"pool1","partition":"p1","fullPath":"/p1/pool1","loadBalancingMode":"round-robin"}]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	defer done()
+	pendingDeploys := utils.NewDeployQueue()
+
+	// in sync.
+	rc.record(bigip, DeployRequest{Partition: "p1", To: testPoolConfig("round-robin"), Context: context.TODO()})
+	rc.check(*rc.applied[bigip.URL+" p1"], pendingDeploys)
+	if rc.Events.Len() != 0 || pendingDeploys.Len() != 0 {
+		t.Errorf("unexpected events %v or deploys %v in sync", rc.Events.Dumps(), pendingDeploys.Dumps())
+	}
+
+	// drifted.
+	rc.record(bigip, DeployRequest{Partition: "p1", To: testPoolConfig("least-connections-member"), Context: context.TODO()})
+	rc.check(*rc.applied[bigip.URL+" p1"], pendingDeploys)
+	if rc.Events.Len() != 1 || pendingDeploys.Len() != 1 {
+		t.Fatalf("unexpected events %v or deploys %v of drift", rc.Events.Dumps(), pendingDeploys.Dumps())
+	}
+	event := rc.Events.Get().(DriftEvent)
+	if event.Status != nil || !event.Reapplying || event.Partition != "p1" || event.BIGIP != bigip.URL ||
+		len(event.Plan.Update) != 1 || event.Plan.Update[0].Name != "pool1" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	r := pendingDeploys.Get().(DeployRequest)
+	if r.Partition != "p1" || r.mode() != DeployMode_Native || !reflect.DeepEqual(*r.To, *testPoolConfig("least-connections-member")) ||
+		r.Context.Value(CtxKey_SpecifiedBIGIP) != bigip.URL || r.Context.Value(ctxKey_Reconcile) == nil {
+		t.Errorf("unexpected re-applying request: %+v", r)
+	}
+
+	// the re-applying request is not recorded.
+	generation := rc.applied[bigip.URL+" p1"].generation
+	rc.record(bigip, r)
+	if rc.applied[bigip.URL+" p1"].generation != generation {
+		t.Errorf("re-applying request is recorded")
+	}
+}
+
+func TestReconciler_check_discarded(t *testing.T) {
+	var rc *Reconciler
+	var bigip *f5_bigip.BIGIP
+	rc, bigip, done := newTestReconciler(t, ReconcilePolicy{AutoApply: true}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mgmt/tm/ltm/pool" {
+			fmt.Fprint(w, `{"items":[]}`)
+			return
+		}
+		// a newer config is applied while checking.
+		rc.record(bigip, DeployRequest{Partition: "p1", To: testPoolConfig("round-robin"), Context: context.TODO()})
+		fmt.Fprint(w, `{"items":[{"name":"pool1","partition":"p1","fullPath":"/p1/pool1","loadBalancingMode":"round-robin"}]}`)
+	})
+	defer done()
+	pendingDeploys := utils.NewDeployQueue()
+
+	rc.record(bigip, DeployRequest{Partition: "p1", To: testPoolConfig("least-connections-member"), Context: context.TODO()})
+	rc.check(*rc.applied[bigip.URL+" p1"], pendingDeploys)
+	if rc.Events.Len() != 0 || pendingDeploys.Len() != 0 {
+		t.Errorf("unexpected events %v or deploys %v of discarded check", rc.Events.Dumps(), pendingDeploys.Dumps())
+	}
+	if rc.applied[bigip.URL+" p1"].generation != 2 {
+		t.Errorf("newer config is not recorded")
+	}
+}
+
+func TestReconciler_check_timeout(t *testing.T) {
+	defer func(timeout time.Duration) { reconcileCheckTimeout = timeout }(reconcileCheckTimeout)
+	reconcileCheckTimeout = 100 * time.Millisecond
+	rc, bigip, done := newTestReconciler(t, ReconcilePolicy{AutoApply: true}, func(w http.ResponseWriter, r *http.Request) {
+		// the BIG-IP not responding.
+		<-r.Context().Done()
+	})
+	defer done()
+	pendingDeploys := utils.NewDeployQueue()
+
+	rc.record(bigip, DeployRequest{Partition: "p1", To: testPoolConfig("round-robin"), Context: context.TODO()})
+	start := time.Now()
+	rc.check(*rc.applied[bigip.URL+" p1"], pendingDeploys)
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("check is not bounded by timeout, took %s", time.Since(start))
+	}
+	if rc.Events.Len() != 1 || pendingDeploys.Len() != 0 {
+		t.Fatalf("unexpected events %v or deploys %v of timeout", rc.Events.Dumps(), pendingDeploys.Dumps())
+	}
+	if event := rc.Events.Get().(DriftEvent); event.Status == nil {
+		t.Errorf("timeout is not reported: %+v", event)
+	}
+}
@@ -0,0 +1,38 @@
+package deployer
+
+import (
+	"fmt"
+
+	f5_bigip "github.com/f5devcentral/f5-bigip-rest-go/bigip"
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
+)
+
+// deployTS deploys the Telemetry Streaming declaration r.To if it differs from the current one,
+// the TS configurations are removed if r.To is nil.
+func deployTS(bc *f5_bigip.BIGIPContext, r DeployRequest) ([]TenantResult, error) {
+	slog := utils.LogFromContext(bc.Context)
+
+	if r.To == nil && r.From == nil {
+		return nil, fmt.Errorf("ts body is empty, quit as error")
+	}
+	declaration := map[string]interface{}{"class": "Telemetry"}
+	if r.To != nil {
+		declaration = *r.To
+	}
+
+	changed, err := bc.TSDeclarationChanged(declaration)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		slog.Infof("ts declaration on %s is not changed, skipping", bc.URL)
+		return []TenantResult{{BIGIP: bc.URL, Code: 200, Message: "no change"}}, nil
+	}
+	if err := bc.ValidateTS(declaration); err != nil {
+		return nil, err
+	}
+	if _, err := bc.DeployTS(declaration); err != nil {
+		return nil, err
+	}
+	return []TenantResult{{BIGIP: bc.URL, Code: 200, Message: "success"}}, nil
+}
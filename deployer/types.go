@@ -2,7 +2,12 @@ package deployer
 
 import (
 	"context"
+	"regexp"
 	"sync"
+	"time"
+
+	f5_bigip "github.com/f5devcentral/f5-bigip-rest-go/bigip"
+	"github.com/f5devcentral/f5-bigip-rest-go/utils"
 )
 
 type DeployRequest struct {
@@ -10,13 +15,33 @@ type DeployRequest struct {
 	From      *map[string]interface{}
 	To        *map[string]interface{}
 	Partition string
-	AS3       bool
-	Context   context.Context
+	// Mode tells how From and To are deployed, DeployMode_Native if not set.
+	Mode DeployMode
+	// Deprecated: use Mode DeployMode_AS3 instead, it's still honored if Mode is not set.
+	AS3     bool
+	Context context.Context
 }
 
+// DeployMode is the backend the configs of DeployRequest are deployed with.
+type DeployMode string
+
+// DeployFunc deploys the configs of the request to the BIG-IP, returning the per-tenant results if there are.
+type DeployFunc func(bc *f5_bigip.BIGIPContext, r DeployRequest) ([]TenantResult, error)
+
 type DeployResponse struct {
 	DeployRequest
 	Status error
+	// Results are the per-tenant results of declarative deployments, like AS3.
+	Results []TenantResult
+}
+
+// TenantResult is the result of deploying a declaration to a tenant on a BIG-IP.
+type TenantResult struct {
+	BIGIP string
+	// Tenant is empty for the declarations not bound to tenants, like DO and TS.
+	Tenant  string
+	Code    int
+	Message string
 }
 
 type DeployResponses struct {
@@ -25,3 +50,68 @@ type DeployResponses struct {
 }
 
 type CtxKeyType string
+
+// DeployerOption customizes the Deployer.
+type DeployerOption func(*deployerOptions)
+
+type deployerOptions struct {
+	reconciler *Reconciler
+}
+
+// Reconciler detects the drift of the partitions deployed by Deployer in DeployMode_Native,
+// comparing the last successfully applied To configs with BIG-IP periodically, see WithReconciler.
+type Reconciler struct {
+	// Events is the queue of DriftEvent, reported when drift is found or the check fails.
+	Events *utils.DeployQueue
+
+	policy   ReconcilePolicy
+	policies map[string]ReconcilePolicy
+	applied  map[string]*appliedConfig
+	mutex    sync.Mutex
+}
+
+// ReconcilePolicy controls how the drift of a partition is checked and handled.
+// The policy is set per partition, the kinds of resources only have their Ignores.
+type ReconcilePolicy struct {
+	// Interval is the period of checking the whole partition, DefaultReconcileInterval if 0.
+	Interval time.Duration
+	// AutoApply re-applies the config through Deployer when drift is found.
+	AutoApply bool
+	// Ignores are the kinds or fields whose drift is ignored.
+	Ignores []DriftIgnore
+}
+
+// DriftIgnore ignores the drift of the resources whose kinds match Kind, a regular expression like "ltm/pool"
+// matching the whole kind, i.e. "ltm/virtual" doesn't match "ltm/virtual-address".
+// Only the Fields are ignored if they're specified, otherwise the whole resources.
+type DriftIgnore struct {
+	Kind   string
+	Fields []string
+
+	// kind is Kind compiled by validate.
+	kind *regexp.Regexp
+}
+
+// DriftEvent reports the drift of a partition on a BIG-IP.
+type DriftEvent struct {
+	BIGIP     string
+	Partition string
+	Time      time.Time
+	// Plan is the changes to bring BIG-IP back to the applied config, nil if the check fails.
+	Plan *f5_bigip.Plan
+	// Reapplying tells the config is queued to Deployer to be re-applied, with AutoApply.
+	Reapplying bool
+	// Status is the error of checking.
+	Status error
+}
+
+// appliedConfig is the last successfully applied config of a partition on a BIG-IP.
+type appliedConfig struct {
+	bigip     *f5_bigip.BIGIP
+	partition string
+	config    map[string]interface{}
+	context   context.Context
+	// generation is increased each time the config is applied, the checks started before are discarded.
+	generation int
+	nextCheck  time.Time
+}
@@ -1,7 +1,49 @@
 package deployer
 
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
 const (
 	CtxKey_DeletePartition CtxKeyType = "delete_partition"
 	CtxKey_CreatePartition CtxKeyType = "create_partition"
 	CtxKey_SpecifiedBIGIP  CtxKeyType = "specified_bigip"
+	// CtxKey_SnapshotUCS flags a high risk request, a UCS archive is saved before it's applied.
+	// The value is the archive name, a generated one is used if it's empty.
+	CtxKey_SnapshotUCS CtxKeyType = "snapshot_ucs"
+	// CtxKey_DryRun validates the request of DeployMode_Native by BIG-IP without committing it, nothing is changed.
+	CtxKey_DryRun CtxKeyType = "dry_run"
+	// ctxKey_Reconcile marks the requests of re-applying by Reconciler, which are not recorded as applied.
+	ctxKey_Reconcile CtxKeyType = "reconcile"
+)
+
+const (
+	// DeployMode_Native deploys the iControl resources with transactions.
+	DeployMode_Native DeployMode = "native"
+	// DeployMode_AS3 deploys the AS3 or ADC declaration.
+	DeployMode_AS3 DeployMode = "as3"
+	// DeployMode_DO deploys the Declarative Onboarding declaration.
+	DeployMode_DO DeployMode = "do"
+	// DeployMode_TS deploys the Telemetry Streaming declaration.
+	DeployMode_TS DeployMode = "ts"
+)
+
+var deployFuncs = map[DeployMode]DeployFunc{}
+
+const (
+	// DefaultReconcileInterval is the period of checking drift if not specified by ReconcilePolicy.
+	DefaultReconcileInterval = 5 * time.Minute
+	// reconcileTick is how often Reconciler looks for the partitions due to check.
+	reconcileTick = time.Second
+)
+
+var (
+	// DriftResourcesCount is the number of drifted resources found by the last check of Reconciler.
+	DriftResourcesCount *prometheus.GaugeVec
+	// DriftChecksCount is the number of checks of Reconciler, by result: in-sync, drifted or failed.
+	DriftChecksCount *prometheus.GaugeVec
+	// reconcileCheckTimeout bounds the check of a partition, for the BIG-IP not responding.
+	reconcileCheckTimeout = time.Minute
 )
@@ -12,8 +12,14 @@ import (
 
 func main() {
 	// instanlize bigip for icontrol execution
+	// more options can be given, i.e. f5_bigip.WithTokenAuth, f5_bigip.WithTimeout
+	bigip, err := f5_bigip.NewBIGIP("https://1.2.3.4", f5_bigip.WithBasicAuth("admin", "password"))
+	if err != nil {
+		fmt.Printf("failed to connect bigip: %s\n", err.Error())
+		os.Exit(1)
+	}
 	bc := f5_bigip.BIGIPContext{
-		BIGIP:   *(f5_bigip.New("https://1.2.3.4", "admin", "password")),
+		BIGIP:   *bigip,
 		Context: context.TODO(),
 	}
 
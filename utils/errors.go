@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RetryError) NeedRetry() bool {
+	return true
+}
+
+func (e *MergedError) Error() string {
+	msgs := []string{}
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, ";")
+}
+
+// NeedRetry returns true if any of the merged errors needs retry.
+func (e *MergedError) NeedRetry() bool {
+	for _, err := range e.Errors {
+		if NeedRetry(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// As makes errors.As look into each of the merged errors.
+func (e *MergedError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Is makes errors.Is look into each of the merged errors.
+func (e *MergedError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *CertificateError) Error() string {
+	if e.URL == "" {
+		return fmt.Sprintf("certificate verification failed: %s", e.Err)
+	}
+	return fmt.Sprintf("certificate verification failed for %s: %s", e.URL, e.Err)
+}
+
+func (e *CertificateError) Unwrap() error {
+	return e.Err
+}
+
+// asCertificateError returns a *CertificateError if err is caused by the certificate verification.
+func asCertificateError(url string, err error) *CertificateError {
+	var certErr *CertificateError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	switch {
+	case errors.As(err, &certErr):
+		return &CertificateError{URL: url, Err: certErr.Err}
+	case errors.As(err, &unknownAuthErr):
+		return &CertificateError{URL: url, Err: unknownAuthErr}
+	case errors.As(err, &hostnameErr):
+		return &CertificateError{URL: url, Err: hostnameErr}
+	case errors.As(err, &invalidErr):
+		return &CertificateError{URL: url, Err: invalidErr}
+	default:
+		return nil
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,7 +14,6 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
-	"regexp"
 	"runtime"
 	"sort"
 	"strings"
@@ -96,10 +96,22 @@ func ThisFuncName() string {
 }
 
 func HttpRequest(client *http.Client, url, method, payload string, headers map[string]string) (int, []byte, error) {
+	return HttpRequestWithContext(context.Background(), client, url, method, payload, headers)
+}
+
+// HttpRequestWithContext does the same as HttpRequest, the request is aborted once ctx is done.
+// The error of an aborted request wraps ctx.Err() and is not retryable.
+func HttpRequestWithContext(ctx context.Context, client *http.Client, url, method, payload string, headers map[string]string) (int, []byte, error) {
+	code, _, body, err := HttpRequestWithHeaders(ctx, client, url, method, payload, headers)
+	return code, body, err
+}
+
+// HttpRequestWithHeaders does the same as HttpRequestWithContext, and returns the response headers as well.
+func HttpRequestWithHeaders(ctx context.Context, client *http.Client, url, method, payload string, headers map[string]string) (int, http.Header, []byte, error) {
 	pd := strings.NewReader(payload)
-	req, err := http.NewRequest(method, url, pd)
+	req, err := http.NewRequestWithContext(ctx, method, url, pd)
 	if err != nil {
-		return 0, nil, err
+		return 0, nil, nil, err
 	}
 	for k, v := range headers {
 		req.Header.Add(k, v)
@@ -107,15 +119,24 @@ func HttpRequest(client *http.Client, url, method, payload string, headers map[s
 
 	res, err := client.Do(req)
 	if err != nil {
-		return 0, nil, RetryErrorf(err.Error())
+		if ctx.Err() != nil {
+			return 0, nil, nil, fmt.Errorf("%s %s aborted: %w", method, url, ctx.Err())
+		}
+		if certErr := asCertificateError(url, err); certErr != nil {
+			return 0, nil, nil, certErr
+		}
+		return 0, nil, nil, RetryErrorf("%w", err)
 	}
 	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return res.StatusCode, nil, err
+		if ctx.Err() != nil {
+			return res.StatusCode, res.Header, nil, fmt.Errorf("%s %s aborted: %w", method, url, ctx.Err())
+		}
+		return res.StatusCode, res.Header, nil, err
 	}
-	return res.StatusCode, body, nil
+	return res.StatusCode, res.Header, body, nil
 }
 
 func HandleCrash(slog *SLOG) {
@@ -363,21 +384,22 @@ func MarshalNoEscaping(v interface{}) ([]byte, error) {
 	return b.Bytes(), err
 }
 
+// RetryErrorf formats an error which is worth retrying, see NeedRetry.
 func RetryErrorf(format string, v ...interface{}) error {
-	return fmt.Errorf(retryMark+format, v)
+	return &RetryError{Err: fmt.Errorf(format, v...)}
 }
 
+// NeedRetry tells if err, or any error it wraps, reports itself as retryable
+// by a NeedRetry() bool method, like *RetryError, *MergedError or f5_bigip.BigipError.
 func NeedRetry(err error) bool {
 	if err == nil {
 		return false
 	}
-	p := fmt.Sprintf("%s.*", retryMark)
-	matched, e := regexp.MatchString(p, err.Error())
-	if e != nil || !matched {
-		return false
-	} else {
-		return true
+	var r retryable
+	if errors.As(err, &r) {
+		return r.NeedRetry()
 	}
+	return false
 }
 
 func FieldsIsExpected(fields, expected interface{}) bool {
@@ -420,18 +442,19 @@ func RequestIdFromContext(ctx context.Context) string {
 	}
 }
 
+// MergeErrors merges the non-nil errors into one *MergedError, nil is returned if there is none.
+// The merged error keeps the original ones, so that errors.As and NeedRetry work with them.
 func MergeErrors(errs []error) error {
-	msgs := []string{}
+	merged := []error{}
 	for _, err := range errs {
 		if err != nil {
-			msgs = append(msgs, err.Error())
+			merged = append(merged, err)
 		}
 	}
 
-	msg := strings.Join(msgs, ";")
-	if msg == "" {
+	if len(merged) == 0 {
 		return nil
 	} else {
-		return fmt.Errorf(msg)
+		return &MergedError{Errors: merged}
 	}
 }
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -337,3 +339,32 @@ func TestDeepEqual(t *testing.T) {
 		})
 	}
 }
+
+func Test_MergeErrors(t *testing.T) {
+	if MergeErrors([]error{nil, nil}) != nil {
+		t.Errorf("merging nil errors should be nil")
+	}
+
+	plain := fmt.Errorf("plain error")
+	retry := RetryErrorf("connection %s", "refused")
+	merged := MergeErrors([]error{plain, nil, retry})
+	if merged.Error() != "plain error;connection refused" {
+		t.Errorf("unexpected message: %s", merged.Error())
+	}
+	if !NeedRetry(merged) {
+		t.Errorf("merged error should be retried if any of them needs retry")
+	}
+	if NeedRetry(MergeErrors([]error{plain})) {
+		t.Errorf("merged error should not be retried if none of them needs retry")
+	}
+	var rerr *RetryError
+	if !errors.As(merged, &rerr) || rerr != retry {
+		t.Errorf("errors.As should find the merged RetryError")
+	}
+	if !errors.Is(merged, plain) {
+		t.Errorf("errors.Is should find the merged plain error")
+	}
+	if !NeedRetry(fmt.Errorf("wrapped: %w", retry)) || NeedRetry(fmt.Errorf("wrapped: %s", retry)) {
+		t.Errorf("NeedRetry should follow wrapped errors only")
+	}
+}
@@ -18,3 +18,24 @@ type DeployQueue struct {
 	found chan bool
 	mutex sync.Mutex
 }
+
+// CertificateError is returned when the server certificate fails the TLS verification.
+// Unlike connection failures, it's not worth retrying.
+type CertificateError struct {
+	URL string
+	Err error
+}
+
+// RetryError marks the wrapped error as worth retrying.
+type RetryError struct {
+	Err error
+}
+
+// MergedError gathers multiple errors, i.e. the failures of one request on multiple BIG-IPs.
+type MergedError struct {
+	Errors []error
+}
+
+type retryable interface {
+	NeedRetry() bool
+}
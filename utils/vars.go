@@ -11,7 +11,6 @@ var (
 )
 
 const (
-	retryMark                   = "__ERROR_TO_RETRY__"
 	CtxKey_RequestID CtxKeyType = "request_id"
 	CtxKey_Logger    CtxKeyType = "logger"
 )